@@ -2,6 +2,7 @@ package mtoken
 
 import (
 	"errors"
+	"strings"
 	"time"
 )
 
@@ -22,6 +23,66 @@ func (r RawClaims) GetInt64(key string) (int64, error) {
 	return 0, errors.New("type is not much")
 }
 
+// GetString returns value as string related to the key.
+func (r RawClaims) GetString(key string) (string, error) {
+	if _, ok := r[key]; !ok {
+		return "", errors.New("key is not found in claims")
+	}
+	if v, ok := r[key].(string); ok {
+		return v, nil
+	}
+	return "", errors.New("type is not much")
+}
+
+// GetStrings returns value as string slice related to the key.
+// A single string is returned as a slice which has one element.
+func (r RawClaims) GetStrings(key string) ([]string, error) {
+	if _, ok := r[key]; !ok {
+		return nil, errors.New("key is not found in claims")
+	}
+	switch v := r[key].(type) {
+	case string:
+		return []string{v}, nil
+	case []string:
+		return v, nil
+	case []interface{}:
+		ss := make([]string, 0, len(v))
+		for _, e := range v {
+			s, ok := e.(string)
+			if !ok {
+				return nil, errors.New("type is not much")
+			}
+			ss = append(ss, s)
+		}
+		return ss, nil
+	}
+	return nil, errors.New("type is not much")
+}
+
+// HasScopes checks that the space-delimited scope claim has all of the scopes.
+// https://tools.ietf.org/html/rfc8693#section-4.2
+func (r RawClaims) HasScopes(scopes ...string) bool {
+	if len(scopes) == 0 {
+		return true
+	}
+	values, err := r.GetStrings("scope")
+	if err != nil {
+		return false
+	}
+	granted := map[string]bool{}
+	for _, v := range values {
+		for _, s := range strings.Fields(v) {
+			granted[s] = true
+		}
+	}
+	for _, s := range scopes {
+		if !granted[s] {
+			return false
+		}
+	}
+	return true
+}
+
 // VerifyExp is check exp
 func (r RawClaims) VerifyExp() bool {
 	exp, err := r.GetInt64("exp")
@@ -42,12 +103,20 @@ func (r RawClaims) VerifyIat() bool {
 
 // GetX5tS256 is check x5t#S256
 func (r RawClaims) GetX5tS256() string {
-	if cnf, ok := r["cnf"].(map[string]interface{}); ok {
-		if s256, ok := cnf["x5t#S256"]; ok {
-			if v, ok := s256.(string); ok {
-				return v
-			}
-		}
+	return r.GetConfirmation(CertificateConfirmation.Member)
+}
+
+// GetConfirmation returns the member of cnf.
+func (r RawClaims) GetConfirmation(member string) string {
+	var cnf map[string]interface{}
+	switch v := r["cnf"].(type) {
+	case map[string]interface{}:
+		cnf = v
+	case RawClaims:
+		cnf = v
+	}
+	if v, ok := cnf[member].(string); ok {
+		return v
 	}
 	return ""
 }
@@ -69,6 +138,10 @@ func NewClaims(claims RawClaims, thumbprint string) (RawClaims, error) {
 }
 
 func addTimeClaims(claims RawClaims) RawClaims {
+	return addTimeClaimsWithLifetime(claims, defaultLifetime)
+}
+
+func addTimeClaimsWithLifetime(claims RawClaims, lifetime time.Duration) RawClaims {
 	now := timeFunc()
 
 	if _, err := claims.GetInt64("iat"); err != nil {
@@ -80,23 +153,27 @@ func addTimeClaims(claims RawClaims) RawClaims {
 		if v, err := claims.GetInt64("iat"); err == nil {
 			iat = time.Unix(v, 0)
 		}
-		claims["exp"] = iat.Add(time.Hour).Unix()
+		claims["exp"] = iat.Add(lifetime).Unix()
 	}
 
 	return claims
 }
 
 func addX5tS256(claims RawClaims, thumbprint string) (RawClaims, error) {
+	return addConfirmation(claims, CertificateConfirmation.Member, thumbprint)
+}
+
+func addConfirmation(claims RawClaims, member, thumbprint string) (RawClaims, error) {
 	if _, ok := claims["cnf"]; !ok {
 		claims["cnf"] = RawClaims{
-			"x5t#S256": thumbprint,
+			member: thumbprint,
 		}
 		return claims, nil
 	}
 
 	if cnf, ok := claims["cnf"].(RawClaims); ok {
-		if _, s256 := cnf["x5t#S256"]; !s256 {
-			cnf["x5t#S256"] = thumbprint
+		if _, exists := cnf[member]; !exists {
+			cnf[member] = thumbprint
 			return claims, nil
 		}
 		return claims, nil
@@ -0,0 +1,252 @@
+package mtoken
+
+import (
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Client authentication methods using the client certificate.
+// https://tools.ietf.org/html/rfc8705#section-2
+const (
+	AuthMethodTLSClientAuth           = "tls_client_auth"
+	AuthMethodSelfSignedTLSClientAuth = "self_signed_tls_client_auth"
+)
+
+// Client is the registration of OAuth client authenticated by the client certificate.
+// With tls_client_auth, one of SubjectDN and SAN* is compared with the
+// certificate issued by the trusted CA. With self_signed_tls_client_auth,
+// the public key of the certificate must be in JWKS.
+// The JSON names are the client metadata of the dynamic client registration.
+// https://tools.ietf.org/html/rfc8705#section-2.1.2
+// https://tools.ietf.org/html/rfc7591#section-2
+type Client struct {
+	ID         string `json:"client_id"`
+	AuthMethod string `json:"token_endpoint_auth_method"`
+
+	SubjectDN string `json:"tls_client_auth_subject_dn,omitempty"`
+	SANDNS    string `json:"tls_client_auth_san_dns,omitempty"`
+	SANURI    string `json:"tls_client_auth_san_uri,omitempty"`
+	SANIP     string `json:"tls_client_auth_san_ip,omitempty"`
+	SANEmail  string `json:"tls_client_auth_san_email,omitempty"`
+
+	JWKS *KeySet `json:"jwks,omitempty"`
+
+	// Scopes are the scopes which the client can request.
+	Scopes []string `json:"scopes,omitempty"`
+
+	// Lifetime is the lifetime of the token issued to the client.
+	// If it is zero, the lifetime of Issuer is used.
+	// It is written in seconds as token_lifetime in JSON.
+	Lifetime time.Duration `json:"-"`
+}
+
+type clientAlias Client
+
+type clientJSON struct {
+	*clientAlias
+	Lifetime int64 `json:"token_lifetime,omitempty"`
+}
+
+// MarshalJSON writes the client metadata.
+func (c *Client) MarshalJSON() ([]byte, error) {
+	return json.Marshal(clientJSON{
+		clientAlias: (*clientAlias)(c),
+		Lifetime:    int64(c.Lifetime / time.Second),
+	})
+}
+
+// UnmarshalJSON reads the client metadata.
+func (c *Client) UnmarshalJSON(b []byte) error {
+	v := clientJSON{clientAlias: (*clientAlias)(c)}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+	c.Lifetime = time.Duration(v.Lifetime) * time.Second
+	return nil
+}
+
+// Validate checks the registration.
+// With tls_client_auth, exactly one of SubjectDN and SAN* must be set.
+// With self_signed_tls_client_auth, JWKS must have the keys.
+func (c *Client) Validate() error {
+	if c.ID == "" {
+		return fmt.Errorf("%w: client_id is required", ErrClientRegistration)
+	}
+
+	switch c.AuthMethod {
+	case AuthMethodTLSClientAuth:
+		n := 0
+		for _, v := range []string{c.SubjectDN, c.SANDNS, c.SANURI, c.SANIP, c.SANEmail} {
+			if v != "" {
+				n++
+			}
+		}
+		if n != 1 {
+			return fmt.Errorf("%w: %s: exactly one of subject dn and san must be set", ErrClientRegistration, c.ID)
+		}
+		if c.SANIP != "" && net.ParseIP(c.SANIP) == nil {
+			return fmt.Errorf("%w: %s: invalid san ip: %q", ErrClientRegistration, c.ID, c.SANIP)
+		}
+		if c.SANURI != "" {
+			if u, err := url.Parse(c.SANURI); err != nil || !u.IsAbs() {
+				return fmt.Errorf("%w: %s: invalid san uri: %q", ErrClientRegistration, c.ID, c.SANURI)
+			}
+		}
+	case AuthMethodSelfSignedTLSClientAuth:
+		if c.JWKS == nil || len(c.JWKS.Keys()) == 0 {
+			return fmt.Errorf("%w: %s: jwks is required", ErrClientRegistration, c.ID)
+		}
+	default:
+		return fmt.Errorf("%w: %s: unsupported token_endpoint_auth_method: %q", ErrClientRegistration, c.ID, c.AuthMethod)
+	}
+
+	for _, scope := range c.Scopes {
+		if scope == "" || strings.ContainsAny(scope, " \"\\") {
+			return fmt.Errorf("%w: %s: invalid scope: %q", ErrClientRegistration, c.ID, scope)
+		}
+	}
+	if c.Lifetime < 0 {
+		return fmt.Errorf("%w: %s: lifetime must not be negative", ErrClientRegistration, c.ID)
+	}
+	return nil
+}
+
+// GrantScopes returns the scopes granted to the client.
+// If requested is empty, all registered scopes are granted.
+// https://tools.ietf.org/html/rfc6749#section-3.3
+func (c *Client) GrantScopes(requested []string) ([]string, error) {
+	if len(requested) == 0 {
+		return c.Scopes, nil
+	}
+	for _, scope := range requested {
+		if !c.hasScope(scope) {
+			return nil, fmt.Errorf("%w: %q", ErrScope, scope)
+		}
+	}
+	return requested, nil
+}
+
+func (c *Client) hasScope(scope string) bool {
+	for _, s := range c.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// Authenticate checks that the client certificate matches the registration.
+// certs[0] must be the client certificate. verified is whether the chain is
+// verified by the TLS handshake or the proxy which terminates TLS.
+// tls_client_auth requires the verified chain, because anyone can create
+// the certificate which has the registered subject DN or SAN.
+// https://tools.ietf.org/html/rfc8705#section-2.1
+func (c *Client) Authenticate(certs []*x509.Certificate, verified bool) error {
+	if len(certs) == 0 {
+		return ErrMutualTLSConnection
+	}
+	cert := certs[0]
+
+	switch c.AuthMethod {
+	case AuthMethodTLSClientAuth:
+		if !verified {
+			return fmt.Errorf("%w: certificate chain is not verified", ErrClientAuth)
+		}
+		if c.matchCertificate(cert) {
+			return nil
+		}
+	case AuthMethodSelfSignedTLSClientAuth:
+		if c.matchPublicKey(cert) {
+			return nil
+		}
+	}
+	return ErrClientAuth
+}
+
+// matchCertificate uses the first registered value.
+// https://tools.ietf.org/html/rfc8705#section-2.1.2
+func (c *Client) matchCertificate(cert *x509.Certificate) bool {
+	switch {
+	case c.SubjectDN != "":
+		return normalizeDN(c.SubjectDN) == normalizeDN(cert.Subject.String())
+	case c.SANDNS != "":
+		for _, name := range cert.DNSNames {
+			if strings.EqualFold(name, c.SANDNS) {
+				return true
+			}
+		}
+	case c.SANURI != "":
+		for _, uri := range cert.URIs {
+			if uri.String() == c.SANURI {
+				return true
+			}
+		}
+	case c.SANIP != "":
+		ip := net.ParseIP(c.SANIP)
+		for _, addr := range cert.IPAddresses {
+			if ip != nil && addr.Equal(ip) {
+				return true
+			}
+		}
+	case c.SANEmail != "":
+		for _, email := range cert.EmailAddresses {
+			if email == c.SANEmail {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (c *Client) matchPublicKey(cert *x509.Certificate) bool {
+	if c.JWKS == nil {
+		return false
+	}
+	jwk, err := NewJWK(cert.PublicKey)
+	if err != nil {
+		return false
+	}
+	for _, k := range c.JWKS.Keys() {
+		if tp, err := k.Thumbprint(); err == nil && tp == jwk.KeyID {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeDN makes the string representation of DN comparable.
+// The spaces around the separators are removed, and the attribute
+// types and values are compared case-insensitively.
+// https://tools.ietf.org/html/rfc4514#section-3
+func normalizeDN(dn string) string {
+	var rdns []string
+	var b strings.Builder
+	escaped := false
+	flush := func() {
+		rdn := b.String()
+		b.Reset()
+		if kv := strings.SplitN(rdn, "=", 2); len(kv) == 2 {
+			rdn = strings.TrimSpace(kv[0]) + "=" + strings.TrimSpace(kv[1])
+		}
+		rdns = append(rdns, strings.ToLower(rdn))
+	}
+	for _, r := range dn {
+		switch {
+		case escaped:
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case r == ',' || r == ';':
+			flush()
+			continue
+		}
+		b.WriteRune(r)
+	}
+	flush()
+	return strings.Join(rdns, ",")
+}
@@ -0,0 +1,198 @@
+package mtoken
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"net"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// clientIdentity sets the subject and SANs which the client registrations match.
+func clientIdentity(c *x509.Certificate) {
+	uri, _ := url.Parse("spiffe://example.com/client")
+	c.Subject = pkix.Name{
+		CommonName:   "client",
+		Organization: []string{"kokukuma"},
+		Country:      []string{"JP"},
+	}
+	c.DNSNames = []string{"client.example.com"}
+	c.URIs = []*url.URL{uri}
+	c.IPAddresses = []net.IP{net.ParseIP("192.168.0.1")}
+	c.EmailAddresses = []string{"client@example.com"}
+}
+
+func TestClientAuthenticate(t *testing.T) {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	otherKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	cert := createCertificate(t, 1, key, nil, nil, clientIdentity)
+	jwk, _ := NewJWK(key)
+	otherJWK, _ := NewJWK(otherKey)
+
+	tcs := map[string]struct {
+		client     Client
+		unverified bool
+		err        error
+	}{
+		"subject dn": {
+			client: Client{AuthMethod: AuthMethodTLSClientAuth, SubjectDN: "CN=client,O=kokukuma,C=JP"},
+		},
+		"subject dn with spaces and case": {
+			client: Client{AuthMethod: AuthMethodTLSClientAuth, SubjectDN: "cn=Client, o=Kokukuma, c=jp"},
+		},
+		"subject dn mismatch": {
+			client: Client{AuthMethod: AuthMethodTLSClientAuth, SubjectDN: "CN=other,O=kokukuma,C=JP"},
+			err:    ErrClientAuth,
+		},
+		"subject dn order": {
+			client: Client{AuthMethod: AuthMethodTLSClientAuth, SubjectDN: "C=JP,O=kokukuma,CN=client"},
+			err:    ErrClientAuth,
+		},
+		"subject dn not verified": {
+			client:     Client{AuthMethod: AuthMethodTLSClientAuth, SubjectDN: "CN=client,O=kokukuma,C=JP"},
+			unverified: true,
+			err:        ErrClientAuth,
+		},
+		"san dns": {
+			client: Client{AuthMethod: AuthMethodTLSClientAuth, SANDNS: "CLIENT.example.com"},
+		},
+		"san uri": {
+			client: Client{AuthMethod: AuthMethodTLSClientAuth, SANURI: "spiffe://example.com/client"},
+		},
+		"san ip": {
+			client: Client{AuthMethod: AuthMethodTLSClientAuth, SANIP: "192.168.0.1"},
+		},
+		"san email": {
+			client: Client{AuthMethod: AuthMethodTLSClientAuth, SANEmail: "client@example.com"},
+		},
+		"san mismatch": {
+			client: Client{AuthMethod: AuthMethodTLSClientAuth, SANDNS: "other.example.com"},
+			err:    ErrClientAuth,
+		},
+		"no registration": {
+			client: Client{AuthMethod: AuthMethodTLSClientAuth},
+			err:    ErrClientAuth,
+		},
+		"self signed": {
+			client: Client{AuthMethod: AuthMethodSelfSignedTLSClientAuth, JWKS: NewKeySet(otherJWK, jwk)},
+		},
+		"self signed not verified": {
+			client:     Client{AuthMethod: AuthMethodSelfSignedTLSClientAuth, JWKS: NewKeySet(jwk)},
+			unverified: true,
+		},
+		"self signed mismatch": {
+			client: Client{AuthMethod: AuthMethodSelfSignedTLSClientAuth, JWKS: NewKeySet(otherJWK)},
+			err:    ErrClientAuth,
+		},
+		"unknown method": {
+			client: Client{AuthMethod: "client_secret_basic", SANDNS: "client.example.com"},
+			err:    ErrClientAuth,
+		},
+	}
+
+	for name, tc := range tcs {
+		err := tc.client.Authenticate([]*x509.Certificate{cert}, !tc.unverified)
+		if !errors.Is(err, tc.err) {
+			t.Errorf("Unexpeccted error occur: %s: expect:%#v, given:%#v", name, tc.err, err)
+		}
+	}
+}
+
+func TestClientValidate(t *testing.T) {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	jwk, _ := NewJWK(key)
+
+	tcs := map[string]struct {
+		client Client
+		err    error
+	}{
+		"tls_client_auth": {
+			client: Client{ID: "client", AuthMethod: AuthMethodTLSClientAuth, SANDNS: "client.example.com", Scopes: []string{"read"}},
+		},
+		"self_signed_tls_client_auth": {
+			client: Client{ID: "client", AuthMethod: AuthMethodSelfSignedTLSClientAuth, JWKS: NewKeySet(jwk)},
+		},
+		"no client_id": {
+			client: Client{AuthMethod: AuthMethodTLSClientAuth, SANDNS: "client.example.com"},
+			err:    ErrClientRegistration,
+		},
+		"no subject": {
+			client: Client{ID: "client", AuthMethod: AuthMethodTLSClientAuth},
+			err:    ErrClientRegistration,
+		},
+		"multiple subjects": {
+			client: Client{ID: "client", AuthMethod: AuthMethodTLSClientAuth, SubjectDN: "CN=client", SANDNS: "client.example.com"},
+			err:    ErrClientRegistration,
+		},
+		"invalid san ip": {
+			client: Client{ID: "client", AuthMethod: AuthMethodTLSClientAuth, SANIP: "example.com"},
+			err:    ErrClientRegistration,
+		},
+		"relative san uri": {
+			client: Client{ID: "client", AuthMethod: AuthMethodTLSClientAuth, SANURI: "/client"},
+			err:    ErrClientRegistration,
+		},
+		"no jwks": {
+			client: Client{ID: "client", AuthMethod: AuthMethodSelfSignedTLSClientAuth},
+			err:    ErrClientRegistration,
+		},
+		"unsupported method": {
+			client: Client{ID: "client", AuthMethod: "client_secret_basic"},
+			err:    ErrClientRegistration,
+		},
+		"invalid scope": {
+			client: Client{ID: "client", AuthMethod: AuthMethodTLSClientAuth, SANDNS: "client.example.com", Scopes: []string{"read write"}},
+			err:    ErrClientRegistration,
+		},
+		"negative lifetime": {
+			client: Client{ID: "client", AuthMethod: AuthMethodTLSClientAuth, SANDNS: "client.example.com", Lifetime: -time.Second},
+			err:    ErrClientRegistration,
+		},
+	}
+
+	for name, tc := range tcs {
+		err := tc.client.Validate()
+		if !errors.Is(err, tc.err) {
+			t.Errorf("Unexpeccted error occur: %s: expect:%#v, given:%#v", name, tc.err, err)
+		}
+	}
+}
+
+func TestClientGrantScopes(t *testing.T) {
+	client := Client{Scopes: []string{"read", "write"}}
+
+	tcs := map[string]struct {
+		requested []string
+		expected  []string
+		err       error
+	}{
+		"all": {
+			expected: []string{"read", "write"},
+		},
+		"subset": {
+			requested: []string{"write"},
+			expected:  []string{"write"},
+		},
+		"not allowed": {
+			requested: []string{"read", "admin"},
+			err:       ErrScope,
+		},
+	}
+
+	for name, tc := range tcs {
+		scopes, err := client.GrantScopes(tc.requested)
+		if !errors.Is(err, tc.err) {
+			t.Errorf("Unexpeccted error occur: %s: expect:%#v, given:%#v", name, tc.err, err)
+			continue
+		}
+		if strings.Join(scopes, " ") != strings.Join(tc.expected, " ") {
+			t.Errorf("Unexpected scopes: %s: expect:%v, given:%v", name, tc.expected, scopes)
+		}
+	}
+}
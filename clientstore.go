@@ -0,0 +1,239 @@
+package mtoken
+
+import (
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// ClientStore is the registry of the clients authenticated by the client certificate.
+// MemoryClientStore and FileClientStore implement it.
+type ClientStore interface {
+	// LookupClient returns the client of clientID.
+	// ErrClientNotFound is returned if it is not registered.
+	LookupClient(clientID string) (*Client, error)
+
+	// LookupClientByCertificate returns the client which the certificate
+	// chain authenticates. certs[0] must be the client certificate.
+	// verified is passed to Client.Authenticate, so tls_client_auth clients
+	// are not found by the chain which is not verified.
+	LookupClientByCertificate(certs []*x509.Certificate, verified bool) (*Client, error)
+}
+
+// MemoryClientStore is ClientStore which keeps the clients in memory.
+type MemoryClientStore struct {
+	mu      sync.RWMutex
+	clients map[string]*Client
+}
+
+// NewMemoryClientStore creates MemoryClientStore which has the clients.
+func NewMemoryClientStore(clients ...*Client) (*MemoryClientStore, error) {
+	s := &MemoryClientStore{}
+	if err := s.SetClients(clients...); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Register validates the client and adds it. The client which has the same
+// client_id is replaced.
+func (s *MemoryClientStore) Register(client *Client) error {
+	if client == nil {
+		return fmt.Errorf("%w: client is nil", ErrClientRegistration)
+	}
+	if err := client.Validate(); err != nil {
+		return err
+	}
+	c := *client
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.clients == nil {
+		s.clients = map[string]*Client{}
+	}
+	s.clients[c.ID] = &c
+	return nil
+}
+
+// Remove removes the client of clientID.
+func (s *MemoryClientStore) Remove(clientID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.clients, clientID)
+}
+
+// SetClients replaces all clients. If one of them is invalid, nothing is changed.
+func (s *MemoryClientStore) SetClients(clients ...*Client) error {
+	m := make(map[string]*Client, len(clients))
+	for _, client := range clients {
+		if client == nil {
+			return fmt.Errorf("%w: client is nil", ErrClientRegistration)
+		}
+		if err := client.Validate(); err != nil {
+			return err
+		}
+		if _, ok := m[client.ID]; ok {
+			return fmt.Errorf("%w: duplicate client_id: %s", ErrClientRegistration, client.ID)
+		}
+		c := *client
+		m[c.ID] = &c
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.clients = m
+	return nil
+}
+
+// LookupClient returns the copy of the client of clientID.
+func (s *MemoryClientStore) LookupClient(clientID string) (*Client, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	client, ok := s.clients[clientID]
+	if !ok {
+		return nil, ErrClientNotFound
+	}
+	c := *client
+	return &c, nil
+}
+
+// LookupClientByCertificate returns the copy of the client which the certificate
+// chain authenticates. If more than one client matches, the client cannot be
+// identified and ErrClientAuth is returned.
+func (s *MemoryClientStore) LookupClientByCertificate(certs []*x509.Certificate, verified bool) (*Client, error) {
+	if len(certs) == 0 {
+		return nil, ErrMutualTLSConnection
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var matched []string
+	for id, client := range s.clients {
+		if client.Authenticate(certs, verified) == nil {
+			matched = append(matched, id)
+		}
+	}
+	switch len(matched) {
+	case 0:
+		return nil, ErrClientNotFound
+	case 1:
+		c := *s.clients[matched[0]]
+		return &c, nil
+	}
+	sort.Strings(matched)
+	return nil, fmt.Errorf("%w: certificate matches multiple clients: %s", ErrClientAuth, strings.Join(matched, ", "))
+}
+
+// FileClientStore is ClientStore which reads the clients from YAML or JSON file.
+// The file has the list of the client metadata under "clients".
+//
+//	clients:
+//	- client_id: client
+//	  token_endpoint_auth_method: tls_client_auth
+//	  tls_client_auth_subject_dn: CN=client,O=example
+//	  scopes: [read, write]
+//	  token_lifetime: 300
+//
+// The file is YAML if the extension is .yaml or .yml, otherwise JSON.
+type FileClientStore struct {
+	path  string
+	store MemoryClientStore
+}
+
+type clientFile struct {
+	Clients []*Client `json:"clients"`
+}
+
+// NewFileClientStore creates FileClientStore and reads the file.
+func NewFileClientStore(path string) (*FileClientStore, error) {
+	s := &FileClientStore{path: path}
+	if err := s.Reload(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Reload reads the file again. If the file is invalid, the current clients are kept.
+func (s *FileClientStore) Reload() error {
+	b, err := ioutil.ReadFile(s.path)
+	if err != nil {
+		return err
+	}
+
+	switch strings.ToLower(filepath.Ext(s.path)) {
+	case ".yaml", ".yml":
+		if b, err = yamlToJSON(b); err != nil {
+			return fmt.Errorf("%w: %s: %v", ErrClientRegistration, s.path, err)
+		}
+	}
+
+	var f clientFile
+	if err := json.Unmarshal(b, &f); err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrClientRegistration, s.path, err)
+	}
+	return s.store.SetClients(f.Clients...)
+}
+
+// LookupClient returns the client of clientID.
+func (s *FileClientStore) LookupClient(clientID string) (*Client, error) {
+	return s.store.LookupClient(clientID)
+}
+
+// LookupClientByCertificate returns the client which the certificate chain authenticates.
+func (s *FileClientStore) LookupClientByCertificate(certs []*x509.Certificate, verified bool) (*Client, error) {
+	return s.store.LookupClientByCertificate(certs, verified)
+}
+
+// yamlToJSON converts YAML to JSON, so that the client metadata is read
+// by the same JSON names, e.g. jwks is read by KeySet.UnmarshalJSON.
+func yamlToJSON(b []byte) ([]byte, error) {
+	var v interface{}
+	if err := yaml.Unmarshal(b, &v); err != nil {
+		return nil, err
+	}
+	v, err := convertYAML(v)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(v)
+}
+
+// convertYAML replaces map[interface{}]interface{} which json cannot marshal.
+func convertYAML(v interface{}) (interface{}, error) {
+	switch t := v.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(t))
+		for k, v := range t {
+			key, ok := k.(string)
+			if !ok {
+				return nil, fmt.Errorf("key must be string: %v", k)
+			}
+			c, err := convertYAML(v)
+			if err != nil {
+				return nil, err
+			}
+			m[key] = c
+		}
+		return m, nil
+	case []interface{}:
+		l := make([]interface{}, len(t))
+		for i, v := range t {
+			c, err := convertYAML(v)
+			if err != nil {
+				return nil, err
+			}
+			l[i] = c
+		}
+		return l, nil
+	}
+	return v, nil
+}
@@ -0,0 +1,158 @@
+package mtoken
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestMemoryClientStore(t *testing.T) {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	certs := []*x509.Certificate{createCertificate(t, 1, key, nil, nil, clientIdentity)}
+
+	store, err := NewMemoryClientStore(
+		&Client{ID: "dns", AuthMethod: AuthMethodTLSClientAuth, SANDNS: "client.example.com"},
+		&Client{ID: "other", AuthMethod: AuthMethodTLSClientAuth, SANDNS: "other.example.com"},
+	)
+	if err != nil {
+		t.Fatalf("Unexpected error occur: expect:%#v", err)
+	}
+
+	client, err := store.LookupClient("dns")
+	if err != nil || client.ID != "dns" {
+		t.Errorf("Unexpeccted error occur: expect:%#v, given:%#v", "dns", err)
+	}
+	if _, err := store.LookupClient("unknown"); !errors.Is(err, ErrClientNotFound) {
+		t.Errorf("Unexpeccted error occur: expect:%#v, given:%#v", ErrClientNotFound, err)
+	}
+	client, err = store.LookupClientByCertificate(certs, true)
+	if err != nil || client.ID != "dns" {
+		t.Errorf("Unexpeccted error occur: expect:%#v, given:%#v", "dns", err)
+	}
+	if _, err := store.LookupClientByCertificate(certs, false); !errors.Is(err, ErrClientNotFound) {
+		t.Errorf("Unexpeccted error occur: expect:%#v, given:%#v", ErrClientNotFound, err)
+	}
+
+	// The copy is returned.
+	client.Scopes = []string{"admin"}
+	if client, _ := store.LookupClient("dns"); len(client.Scopes) != 0 {
+		t.Errorf("Registration must not be changed: %v", client.Scopes)
+	}
+
+	if err := store.Register(&Client{ID: "uri", AuthMethod: AuthMethodTLSClientAuth, SANURI: "spiffe://example.com/client"}); err != nil {
+		t.Fatalf("Unexpected error occur: expect:%#v", err)
+	}
+	if _, err := store.LookupClientByCertificate(certs, true); !errors.Is(err, ErrClientAuth) {
+		t.Errorf("Unexpeccted error occur: expect:%#v, given:%#v", ErrClientAuth, err)
+	}
+
+	store.Remove("dns")
+	store.Remove("uri")
+	if _, err := store.LookupClientByCertificate(certs, true); !errors.Is(err, ErrClientNotFound) {
+		t.Errorf("Unexpeccted error occur: expect:%#v, given:%#v", ErrClientNotFound, err)
+	}
+
+	if err := store.Register(&Client{ID: "invalid", AuthMethod: AuthMethodTLSClientAuth}); !errors.Is(err, ErrClientRegistration) {
+		t.Errorf("Unexpeccted error occur: expect:%#v, given:%#v", ErrClientRegistration, err)
+	}
+	if _, err := NewMemoryClientStore(
+		&Client{ID: "dns", AuthMethod: AuthMethodTLSClientAuth, SANDNS: "client.example.com"},
+		&Client{ID: "dns", AuthMethod: AuthMethodTLSClientAuth, SANDNS: "other.example.com"},
+	); !errors.Is(err, ErrClientRegistration) {
+		t.Errorf("Unexpeccted error occur: expect:%#v, given:%#v", ErrClientRegistration, err)
+	}
+}
+
+func TestFileClientStore(t *testing.T) {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	certs := []*x509.Certificate{createCertificate(t, 1, key, nil, nil, clientIdentity)}
+	jwk, _ := NewJWK(key)
+	jwks, _ := json.Marshal(NewKeySet(jwk))
+
+	dir, err := ioutil.TempDir("", "clientstore")
+	if err != nil {
+		t.Fatalf("Unexpected error occur: expect:%#v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	tcs := map[string]struct {
+		file    string
+		content string
+	}{
+		"yaml": {
+			file: "clients.yaml",
+			content: `
+clients:
+- client_id: client
+  token_endpoint_auth_method: tls_client_auth
+  tls_client_auth_subject_dn: CN=client,O=kokukuma,C=JP
+  scopes: [read, write]
+  token_lifetime: 300
+- client_id: self-signed
+  token_endpoint_auth_method: self_signed_tls_client_auth
+  jwks: ` + string(jwks) + `
+`,
+		},
+		"json": {
+			file: "clients.json",
+			content: `{"clients": [
+  {"client_id": "client", "token_endpoint_auth_method": "tls_client_auth",
+   "tls_client_auth_subject_dn": "CN=client,O=kokukuma,C=JP",
+   "scopes": ["read", "write"], "token_lifetime": 300},
+  {"client_id": "self-signed", "token_endpoint_auth_method": "self_signed_tls_client_auth",
+   "jwks": ` + string(jwks) + `}
+]}`,
+		},
+	}
+
+	for name, tc := range tcs {
+		path := filepath.Join(dir, tc.file)
+		if err := ioutil.WriteFile(path, []byte(tc.content), 0600); err != nil {
+			t.Fatalf("Unexpected error occur: expect:%#v", err)
+		}
+		store, err := NewFileClientStore(path)
+		if err != nil {
+			t.Errorf("Unexpeccted error occur: %s: %#v", name, err)
+			continue
+		}
+
+		client, err := store.LookupClient("client")
+		if err != nil {
+			t.Errorf("Unexpeccted error occur: %s: %#v", name, err)
+			continue
+		}
+		if client.Lifetime != 5*time.Minute || len(client.Scopes) != 2 {
+			t.Errorf("Unexpected client: %s: %#v", name, client)
+		}
+		if err := client.Authenticate(certs, true); err != nil {
+			t.Errorf("Unexpeccted error occur: %s: %#v", name, err)
+		}
+		client, err = store.LookupClient("self-signed")
+		if err != nil {
+			t.Errorf("Unexpeccted error occur: %s: %#v", name, err)
+			continue
+		}
+		if err := client.Authenticate(certs, true); err != nil {
+			t.Errorf("Unexpeccted error occur: %s: %#v", name, err)
+		}
+
+		// The invalid file does not replace the clients.
+		if err := ioutil.WriteFile(path, []byte(`{"clients": [{"client_id": "client"}]}`), 0600); err != nil {
+			t.Fatalf("Unexpected error occur: expect:%#v", err)
+		}
+		if err := store.Reload(); !errors.Is(err, ErrClientRegistration) {
+			t.Errorf("Unexpeccted error occur: %s: expect:%#v, given:%#v", name, ErrClientRegistration, err)
+		}
+		if _, err := store.LookupClient("client"); err != nil {
+			t.Errorf("Unexpeccted error occur: %s: %#v", name, err)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package mtoken
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Auth schemes of Authorization header.
+// For DPoP, only the access token is extracted. The DPoP proof is out of
+// scope of this package, so the server which accepts DPoP must validate
+// the DPoP header by itself with the scheme of the credential.
+// https://tools.ietf.org/html/rfc9449#section-7.1
+const (
+	SchemeBearer = "Bearer"
+	SchemeDPoP   = "DPoP"
+)
+
+// Credential is the token on Authorization header.
+// Scheme is one of the accepted schemes, written as it is configured.
+type Credential struct {
+	Scheme string
+	Token  string
+}
+
+// CredentialExtractor parses Authorization header of http and gRPC.
+type CredentialExtractor struct {
+	schemes []string
+}
+
+// DefaultCredentialExtractor accepts Bearer.
+var DefaultCredentialExtractor = NewCredentialExtractor(SchemeBearer)
+
+// NewCredentialExtractor creates CredentialExtractor which accepts the schemes.
+// If no scheme is given, Bearer is accepted.
+func NewCredentialExtractor(schemes ...string) *CredentialExtractor {
+	if len(schemes) == 0 {
+		schemes = []string{SchemeBearer}
+	}
+	return &CredentialExtractor{schemes: schemes}
+}
+
+// Schemes returns the accepted schemes.
+func (e *CredentialExtractor) Schemes() []string {
+	return append([]string(nil), e.schemes...)
+}
+
+// Extract parses the values of Authorization header.
+// The scheme is matched case-insensitively. The request which has
+// more than one credential is rejected as ErrInvalidCredential.
+// https://tools.ietf.org/html/rfc7235#section-2.1
+func (e *CredentialExtractor) Extract(values []string) (*Credential, error) {
+	if len(values) == 0 {
+		return nil, ErrNoCredential
+	}
+	if len(values) > 1 {
+		return nil, fmt.Errorf("%w: multiple client auth tokens", ErrInvalidCredential)
+	}
+
+	parts := strings.SplitN(strings.TrimSpace(values[0]), " ", 2)
+	if len(parts) != 2 {
+		return nil, ErrInvalidCredential
+	}
+	token := strings.TrimLeft(parts[1], " ")
+	if !isToken68(token) {
+		return nil, ErrInvalidCredential
+	}
+
+	for _, scheme := range e.schemes {
+		if strings.EqualFold(parts[0], scheme) {
+			return &Credential{Scheme: scheme, Token: token}, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: token_type should be %s: %q", ErrInvalidCredential, strings.Join(e.schemes, " or "), parts[0])
+}
+
+// isToken68 checks the syntax of the credentials.
+// It also rejects the comma-separated credentials.
+// https://tools.ietf.org/html/rfc7235#section-2.1
+func isToken68(s string) bool {
+	s = strings.TrimRight(s, "=")
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		switch {
+		case 'a' <= r && r <= 'z', 'A' <= r && r <= 'Z', '0' <= r && r <= '9':
+		case strings.ContainsRune("-._~+/", r):
+		default:
+			return false
+		}
+	}
+	return true
+}
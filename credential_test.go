@@ -0,0 +1,109 @@
+package mtoken
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCredentialExtractor(t *testing.T) {
+	bearer := NewCredentialExtractor()
+	both := NewCredentialExtractor(SchemeBearer, SchemeDPoP)
+
+	tcs := map[string]struct {
+		extractor *CredentialExtractor
+		values    []string
+		cred      *Credential
+		err       error
+	}{
+		"bearer": {
+			extractor: bearer,
+			values:    []string{"Bearer abc.def.ghi"},
+			cred:      &Credential{Scheme: SchemeBearer, Token: "abc.def.ghi"},
+		},
+		"lower case": {
+			extractor: bearer,
+			values:    []string{"bearer abc.def.ghi"},
+			cred:      &Credential{Scheme: SchemeBearer, Token: "abc.def.ghi"},
+		},
+		"upper case": {
+			extractor: bearer,
+			values:    []string{"BEARER abc.def.ghi"},
+			cred:      &Credential{Scheme: SchemeBearer, Token: "abc.def.ghi"},
+		},
+		"extra spaces": {
+			extractor: bearer,
+			values:    []string{" Bearer   abc.def.ghi "},
+			cred:      &Credential{Scheme: SchemeBearer, Token: "abc.def.ghi"},
+		},
+		"token68 padding": {
+			extractor: bearer,
+			values:    []string{"Bearer YWJj+/_-~=="},
+			cred:      &Credential{Scheme: SchemeBearer, Token: "YWJj+/_-~=="},
+		},
+		"dpop": {
+			extractor: both,
+			values:    []string{"dpop abc.def.ghi"},
+			cred:      &Credential{Scheme: SchemeDPoP, Token: "abc.def.ghi"},
+		},
+		"dpop not accepted": {
+			extractor: bearer,
+			values:    []string{"DPoP abc.def.ghi"},
+			err:       ErrInvalidCredential,
+		},
+		"basic": {
+			extractor: bearer,
+			values:    []string{"Basic dXNlcjpwYXNz"},
+			err:       ErrInvalidCredential,
+		},
+		"no value": {
+			extractor: bearer,
+			err:       ErrNoCredential,
+		},
+		"no token": {
+			extractor: bearer,
+			values:    []string{"Bearer"},
+			err:       ErrInvalidCredential,
+		},
+		"empty token": {
+			extractor: bearer,
+			values:    []string{"Bearer   "},
+			err:       ErrInvalidCredential,
+		},
+		"duplicate": {
+			extractor: bearer,
+			values:    []string{"Bearer abc", "Bearer abc"},
+			err:       ErrInvalidCredential,
+		},
+		"conflicting": {
+			extractor: both,
+			values:    []string{"Bearer abc", "DPoP def"},
+			err:       ErrInvalidCredential,
+		},
+		"comma-separated": {
+			extractor: bearer,
+			values:    []string{"Bearer abc, Bearer def"},
+			err:       ErrInvalidCredential,
+		},
+		"invalid character": {
+			extractor: bearer,
+			values:    []string{`Bearer abc"def`},
+			err:       ErrInvalidCredential,
+		},
+	}
+
+	for name, tc := range tcs {
+		cred, err := tc.extractor.Extract(tc.values)
+		if tc.err != nil {
+			if !errors.Is(err, tc.err) {
+				t.Errorf("Unexpeccted error occur: %s: expect:%#v, given:%#v", name, tc.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("Unexpected error occur in %s: expect:%#v", name, err)
+		}
+		if *cred != *tc.cred {
+			t.Errorf("Unexpected credential: %s: expect:%#v, given:%#v", name, tc.cred, cred)
+		}
+	}
+}
@@ -1,14 +1,15 @@
 package mtoken
 
 import (
+	"crypto"
 	"crypto/ecdsa"
 	"crypto/rand"
+	"encoding/asn1"
 	"errors"
+	"fmt"
 	"math/big"
 )
 
-const ()
-
 // ES256 represent Signature algorithm.
 type ES256 struct {
 }
@@ -18,31 +19,103 @@ func (e ES256) Name() string {
 	return "ES256"
 }
 
+// Sign creates signature
+func (e ES256) Sign(key interface{}, ss string) ([]byte, error) {
+	return signECDSA(es256, key, ss)
+}
+
+// Verify exec verify signature
+func (e ES256) Verify(key interface{}, ss string, sig []byte) error {
+	return verifyECDSA(es256, key, ss, sig)
+}
+
+// ES384 represent Signature algorithm.
+type ES384 struct {
+}
+
+// Name returns alg name.
+func (e ES384) Name() string {
+	return "ES384"
+}
+
+// Sign creates signature
+func (e ES384) Sign(key interface{}, ss string) ([]byte, error) {
+	return signECDSA(es384, key, ss)
+}
+
+// Verify exec verify signature
+func (e ES384) Verify(key interface{}, ss string, sig []byte) error {
+	return verifyECDSA(es384, key, ss, sig)
+}
+
+// ES512 represent Signature algorithm.
+type ES512 struct {
+}
+
+// Name returns alg name.
+func (e ES512) Name() string {
+	return "ES512"
+}
+
+// Sign creates signature
+func (e ES512) Sign(key interface{}, ss string) ([]byte, error) {
+	return signECDSA(es512, key, ss)
+}
+
+// Verify exec verify signature
+func (e ES512) Verify(key interface{}, ss string, sig []byte) error {
+	return verifyECDSA(es512, key, ss, sig)
+}
+
+// ecdsaParams is the curve and the hash used by the ECDSA algorithm.
+// https://tools.ietf.org/html/rfc7518#section-3.4
+type ecdsaParams struct {
+	name    string
+	hash    crypto.Hash
+	bitSize int
+	keySize int
+}
+
+var (
+	es256 = ecdsaParams{name: "ES256", hash: crypto.SHA256, bitSize: 256, keySize: 32}
+	es384 = ecdsaParams{name: "ES384", hash: crypto.SHA384, bitSize: 384, keySize: 48}
+	es512 = ecdsaParams{name: "ES512", hash: crypto.SHA512, bitSize: 521, keySize: 66}
+)
+
 type ecdsaSignature struct {
 	R, S *big.Int
 }
 
-// Sign creates signature
-func (e ES256) Sign(key interface{}, ss string) ([]byte, error) {
-	k, ok := key.(*ecdsa.PrivateKey)
+func signECDSA(p ecdsaParams, key interface{}, ss string) ([]byte, error) {
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, ErrKeyType
+	}
+	pub, ok := signer.Public().(*ecdsa.PublicKey)
 	if !ok {
-		return nil, errors.New("Unexpected key type")
+		return nil, ErrKeyType
 	}
 
 	// Check the length of ecdsa key
-	if k.Curve.Params().BitSize != 256 {
-		return nil, errors.New("key length must be 256 as ES256")
+	if pub.Curve.Params().BitSize != p.bitSize {
+		return nil, fmt.Errorf("key length must be %d as %s", p.bitSize, p.name)
 	}
 
 	// 1. Generate a digital signature
-	r, s, err := ecdsa.Sign(rand.Reader, k, execSha256(ss))
+	// crypto.Signer returns ASN.1 DER encoded signature.
+	der, err := signer.Sign(rand.Reader, execHash(p.hash, ss), p.hash)
 	if err != nil {
 		return nil, errors.New("Failed to sign")
 	}
+	var sig ecdsaSignature
+	rest, err := asn1.Unmarshal(der, &sig)
+	if err != nil || len(rest) != 0 {
+		return nil, errors.New("Failed to sign")
+	}
 
 	// 2. octet sequences in big-endian order
-	rByte := padding(r.Bytes(), 32)
-	sByte := padding(s.Bytes(), 32)
+	rByte := padding(sig.R.Bytes(), p.keySize)
+	sByte := padding(sig.S.Bytes(), p.keySize)
 
 	// 3. Concatenate the two octet sequences in the order R and then S.
 	return append(rByte, sByte...), nil
@@ -56,22 +129,23 @@ func padding(b []byte, l int) []byte {
 	return append(pad, b...)
 }
 
-// Verify exec verify signature
-func (e ES256) Verify(key interface{}, ss string, sig []byte) error {
+func verifyECDSA(p ecdsaParams, key interface{}, ss string, sig []byte) error {
 	k, ok := key.(*ecdsa.PublicKey)
 	if !ok {
-		return errors.New("Unexpected key type")
+		return ErrKeyType
+	}
+	if k.Curve.Params().BitSize != p.bitSize {
+		return ErrKeyType
+	}
+	if len(sig) != 2*p.keySize {
+		return ErrSignature
 	}
 
-	rByte := sig[:32]
-	sByte := sig[32:]
-
-	r := big.NewInt(0).SetBytes(rByte)
-	s := big.NewInt(0).SetBytes(sByte)
+	r := big.NewInt(0).SetBytes(sig[:p.keySize])
+	s := big.NewInt(0).SetBytes(sig[p.keySize:])
 
-	status := ecdsa.Verify(k, execSha256(ss), r, s)
-	if status != true {
-		return errors.New("Failed to verify")
+	if !ecdsa.Verify(k, execHash(p.hash, ss), r, s) {
+		return ErrSignature
 	}
 	return nil
 }
@@ -1,6 +1,9 @@
 package mtoken
 
 import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
 	"testing"
 )
 
@@ -108,3 +111,68 @@ func TestPadding(t *testing.T) {
 		}
 	}
 }
+
+func TestESSignVerifyCurves(t *testing.T) {
+	p256, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	p384, _ := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	p521, _ := ecdsa.GenerateKey(elliptic.P521(), rand.Reader)
+
+	tcs := map[string]struct {
+		method  Method
+		key     *ecdsa.PrivateKey
+		sigSize int
+	}{
+		"ES256": {
+			method:  ES256{},
+			key:     p256,
+			sigSize: 64,
+		},
+		"ES384": {
+			method:  ES384{},
+			key:     p384,
+			sigSize: 96,
+		},
+		"ES512": {
+			method:  ES512{},
+			key:     p521,
+			sigSize: 132,
+		},
+	}
+
+	for name, tc := range tcs {
+		sign, err := tc.method.Sign(tc.key, "sample")
+		if err != nil {
+			t.Fatalf("Unexpected error occur in %s: expect:%#v", name, err)
+		}
+		if len(sign) != tc.sigSize {
+			t.Errorf("Unexpected signature length in %s: expect:%#v, given:%#v", name, tc.sigSize, len(sign))
+		}
+		if err := tc.method.Verify(&tc.key.PublicKey, "sample", sign); err != nil {
+			t.Fatalf("Unexpected error occur in %s: expect:%#v", name, err)
+		}
+		if err := tc.method.Verify(&tc.key.PublicKey, "sample", sign[1:]); err != ErrSignature {
+			t.Errorf("Unexpected error occur in %s: expect:%#v, given:%#v", name, ErrSignature, err)
+		}
+	}
+}
+
+func TestESCurveMismatch(t *testing.T) {
+	p256, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	p521, _ := ecdsa.GenerateKey(elliptic.P521(), rand.Reader)
+
+	sign, err := ES512{}.Sign(p521, "sample")
+	if err != nil {
+		t.Fatalf("Unexpected error occur: expect:%#v", err)
+	}
+	if err := (ES512{}).Verify(&p256.PublicKey, "sample", sign); err != ErrKeyType {
+		t.Errorf("Unexpected error occur: expect:%#v, given:%#v", ErrKeyType, err)
+	}
+	if err := (ES256{}).Verify(&p521.PublicKey, "sample", sign); err != ErrKeyType {
+		t.Errorf("Unexpected error occur: expect:%#v, given:%#v", ErrKeyType, err)
+	}
+
+	_, err = ES512{}.Sign(p256, "sample")
+	if err == nil || err.Error() != "key length must be 521 as ES512" {
+		t.Errorf("Unexpected error occur: given:%#v", err)
+	}
+}
@@ -0,0 +1,46 @@
+package mtoken
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+)
+
+// EdDSA represent Signature algorithm.
+// Only Ed25519 is supported.
+// https://tools.ietf.org/html/rfc8037#section-3.1
+type EdDSA struct {
+}
+
+// Name returns alg name.
+func (e EdDSA) Name() string {
+	return "EdDSA"
+}
+
+// Sign creates signature
+func (e EdDSA) Sign(key interface{}, ss string) ([]byte, error) {
+	if k, ok := key.(ed25519.PrivateKey); ok && len(k) != ed25519.PrivateKeySize {
+		return nil, ErrKeyType
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, ErrKeyType
+	}
+	if _, ok := signer.Public().(ed25519.PublicKey); !ok {
+		return nil, ErrKeyType
+	}
+	// Ed25519 signs the message itself without hashing.
+	return signer.Sign(rand.Reader, []byte(ss), crypto.Hash(0))
+}
+
+// Verify exec verify signature
+func (e EdDSA) Verify(key interface{}, ss string, sig []byte) error {
+	k, ok := key.(ed25519.PublicKey)
+	if !ok || len(k) != ed25519.PublicKeySize {
+		return ErrKeyType
+	}
+	if !ed25519.Verify(k, []byte(ss), sig) {
+		return ErrSignature
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+package mtoken
+
+import (
+	"encoding/base64"
+	"testing"
+)
+
+func getEd25519PrivateKey() (interface{}, error) {
+	key := testingKey(`-----BEGIN TESTING KEY-----
+MC4CAQAwBQYDK2VwBCIEIJ1hsZ3v/VpguoRK9JLsLMREScVpezJpGXA7rAMcrn9g
+-----END TESTING KEY-----`)
+	return GetPrivateKey([]byte(key))
+}
+
+func getEd25519PublicKey() (interface{}, error) {
+	key := `-----BEGIN PUBLIC KEY-----
+MCowBQYDK2VwAyEA11qYAYKxCrfVS/7TyWQHOg7hcvPapiMlrwIaaPcHURo=
+-----END PUBLIC KEY-----`
+	return GetPublicKey([]byte(key))
+}
+
+// RFC 8037 Appendix A.4
+func TestEdDSARFC8037(t *testing.T) {
+	priv, err := getEd25519PrivateKey()
+	if err != nil {
+		t.Fatalf("Unexpected error occur: expect:%#v", err)
+	}
+	pub, err := getEd25519PublicKey()
+	if err != nil {
+		t.Fatalf("Unexpected error occur: expect:%#v", err)
+	}
+
+	signingInput := "eyJhbGciOiJFZERTQSJ9.RXhhbXBsZSBvZiBFZDI1NTE5IHNpZ25pbmc"
+	expect := "hgyY0il_MGCjP0JzlnLWG1PPOt7-09PGcvMg3AIbQR6dWbhijcNR4ki4iylGjg5BhVsPt9g7sVvpAr_MuM0KAg"
+
+	ed := EdDSA{}
+	sign, err := ed.Sign(priv, signingInput)
+	if err != nil {
+		t.Fatalf("Unexpected error occur: expect:%#v", err)
+	}
+	if actual := base64.RawURLEncoding.EncodeToString(sign); actual != expect {
+		t.Errorf("Unexpected signature: expect:%#v, given:%#v", expect, actual)
+	}
+	if err := ed.Verify(pub, signingInput, sign); err != nil {
+		t.Errorf("Unexpected error occur: expect:%#v", err)
+	}
+	if err := ed.Verify(pub, signingInput+".", sign); err != ErrSignature {
+		t.Errorf("Unexpected error occur: expect:%#v, given:%#v", ErrSignature, err)
+	}
+}
+
+func TestEdDSAIssueDecode(t *testing.T) {
+	priv, err := getEd25519PrivateKey()
+	if err != nil {
+		t.Fatalf("Unexpected error occur: expect:%#v", err)
+	}
+	pub, err := getEd25519PublicKey()
+	if err != nil {
+		t.Fatalf("Unexpected error occur: expect:%#v", err)
+	}
+
+	state := getTLSState()
+	tokenStr, err := IssueToken(state, priv, RawClaims{"iss": "iss"})
+	if err != nil {
+		t.Fatalf("Unexpected error occur: expect:%#v", err)
+	}
+	jwt, err := DecodeToken(state, tokenStr, pub)
+	if err != nil {
+		t.Fatalf("Unexpected error occur: expect:%#v", err)
+	}
+	if alg, _ := jwt.Header().GetString("alg"); alg != "EdDSA" {
+		t.Errorf("Unexpected alg: expect:%#v, given:%#v", "EdDSA", alg)
+	}
+}
+
+func TestEdDSASignFailed(t *testing.T) {
+	rsaKey, err := getPrivateKey()
+	if err != nil {
+		t.Fatalf("Unexpected error occur: expect:%#v", err)
+	}
+
+	tcs := map[string]struct {
+		privKey  interface{}
+		contents string
+		err      string
+	}{
+		"empty key": {
+			privKey:  "",
+			contents: "sample",
+			err:      "Unexpected key type",
+		},
+		"rsa key": {
+			privKey:  rsaKey,
+			contents: "sample",
+			err:      "Unexpected key type",
+		},
+	}
+
+	for name, tc := range tcs {
+		_, err := EdDSA{}.Sign(tc.privKey, tc.contents)
+		if err == nil {
+			t.Fatalf("Should be error occur in %s", name)
+		}
+		if err.Error() != tc.err {
+			t.Errorf("Unexpeccted error occur: %s: expect:%#v, given:%#v", name, tc.err, err.Error())
+		}
+	}
+}
@@ -6,6 +6,30 @@ var (
 	// ErrKeyPair is used for invalid private key.
 	ErrKeyPair = errors.New("invalid key pair")
 
+	// ErrKeyType is used when the key type does not match the method.
+	ErrKeyType = errors.New("Unexpected key type")
+
+	// ErrKeyNotFound is used when no key matches kid.
+	ErrKeyNotFound = errors.New("key is not found in key set")
+
+	// ErrKeyNotInPEM is used when no key is found in PEM data.
+	ErrKeyNotInPEM = errors.New("key is not found in PEM data")
+
+	// ErrEncryptedKey is used when the private key is encrypted but no password is given.
+	ErrEncryptedKey = errors.New("private key is encrypted")
+
+	// ErrIncorrectPassword is used when the encrypted private key cannot be decrypted.
+	ErrIncorrectPassword = errors.New("incorrect password for private key")
+
+	// ErrSignature is used when the signature is invalid.
+	ErrSignature = errors.New("Failed to verify")
+
+	// ErrUnsupportedAlg is used when alg is not supported.
+	ErrUnsupportedAlg = errors.New("unsupported signature algorithm")
+
+	// ErrTokenMalformed is used when the token cannot be parsed.
+	ErrTokenMalformed = errors.New("malformed token")
+
 	// ErrVerifyPoP occers
 	ErrVerifyPoP = errors.New("failed to verify proof of possession")
 
@@ -15,9 +39,128 @@ var (
 	// ErrTokenIat occers
 	ErrTokenIat = errors.New("this token cannot be used now")
 
+	// ErrTokenNotBefore occers
+	ErrTokenNotBefore = errors.New("this token is not valid yet")
+
+	// ErrTokenIssuer occers
+	ErrTokenIssuer = errors.New("this token is issued by unexpected issuer")
+
+	// ErrTokenAudience occers
+	ErrTokenAudience = errors.New("this token is not intended for this audience")
+
+	// ErrTokenInactive is used when the introspection says the token is not active.
+	ErrTokenInactive = errors.New("this token is not active")
+
+	// ErrTokenClaims occers
+	ErrTokenClaims = errors.New("required claims are not found in this token")
+
 	// ErrMutualTLSConnection is used when the connection is not TLS
 	ErrMutualTLSConnection = errors.New("connection must be used mutual TLS")
 
+	// ErrNoCredential is used when the request has no Authorization header.
+	ErrNoCredential = errors.New("no client auth token")
+
+	// ErrInvalidCredential is used when Authorization header cannot be parsed.
+	ErrInvalidCredential = errors.New("invalid client auth format")
+
+	// ErrClientAuth is used when the client certificate does not match the registration.
+	ErrClientAuth = errors.New("client authentication failed")
+
+	// ErrClientNotFound is used when the client is not registered.
+	ErrClientNotFound = errors.New("client is not found")
+
+	// ErrClientRegistration is used when the client registration is invalid.
+	ErrClientRegistration = errors.New("invalid client registration")
+
+	// ErrScope is used when the client requests the scope which is not allowed.
+	ErrScope = errors.New("requested scope is not allowed")
+
 	// ErrTokenStruct is used when the token struct is empty.
 	ErrTokenStruct = errors.New("unknown token struct type")
 )
+
+// Reason is the reason why token verification failed.
+type Reason int
+
+// Reasons of ValidationError.
+const (
+	ReasonMalformed Reason = iota + 1
+	ReasonSignature
+	ReasonExpired
+	ReasonNotYetValid
+	ReasonIssuer
+	ReasonAudience
+	ReasonPoPMismatch
+	ReasonUnsupportedAlg
+	ReasonKeyType
+)
+
+var reasonNames = map[Reason]string{
+	ReasonMalformed:      "malformed",
+	ReasonSignature:      "signature",
+	ReasonExpired:        "expired",
+	ReasonNotYetValid:    "not-yet-valid",
+	ReasonIssuer:         "issuer",
+	ReasonAudience:       "audience",
+	ReasonPoPMismatch:    "pop-mismatch",
+	ReasonUnsupportedAlg: "unsupported-alg",
+	ReasonKeyType:        "key-type",
+}
+
+// reasonErrors is the sentinel error matched by each reason.
+var reasonErrors = map[Reason]error{
+	ReasonMalformed:      ErrTokenMalformed,
+	ReasonSignature:      ErrSignature,
+	ReasonExpired:        ErrTokenExpire,
+	ReasonNotYetValid:    ErrTokenIat,
+	ReasonIssuer:         ErrTokenIssuer,
+	ReasonAudience:       ErrTokenAudience,
+	ReasonPoPMismatch:    ErrVerifyPoP,
+	ReasonUnsupportedAlg: ErrUnsupportedAlg,
+	ReasonKeyType:        ErrKeyType,
+}
+
+// String returns the name of the reason.
+func (r Reason) String() string {
+	if name, ok := reasonNames[r]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+// ValidationError is returned when token verification failed.
+// errors.Is reports true for the sentinel error of the reason,
+// e.g. ErrTokenExpire for ReasonExpired, and for a *ValidationError
+// which has the same reason.
+type ValidationError struct {
+	Reason Reason
+	Err    error
+}
+
+func newValidationError(reason Reason, err error) *ValidationError {
+	return &ValidationError{
+		Reason: reason,
+		Err:    err,
+	}
+}
+
+// Error returns the message of the wrapped error.
+func (e *ValidationError) Error() string {
+	if e.Err != nil {
+		return e.Err.Error()
+	}
+	return "token validation failed: " + e.Reason.String()
+}
+
+// Unwrap returns the wrapped error.
+func (e *ValidationError) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether target matches the reason of the error.
+func (e *ValidationError) Is(target error) bool {
+	if t, ok := target.(*ValidationError); ok {
+		return t.Reason == e.Reason
+	}
+	return target == reasonErrors[e.Reason]
+}
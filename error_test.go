@@ -0,0 +1,106 @@
+package mtoken
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestValidationError(t *testing.T) {
+	secret := []byte("secret")
+	state := getTLSState()
+	tokenStr, err := IssueToken(state, secret, RawClaims{"iss": "iss"})
+	if err != nil {
+		t.Fatalf("Unexpected error occur: expect:%#v", err)
+	}
+	rsaKey, err := getPublicKey()
+	if err != nil {
+		t.Fatalf("Unexpected error occur: expect:%#v", err)
+	}
+	sign := func(claims RawClaims) string {
+		token, err := NewJWT(RawHeader{"typ": "JWT"}, claims, HS256{}).signJWT(secret)
+		if err != nil {
+			t.Fatalf("Unexpected error occur: expect:%#v", err)
+		}
+		return token
+	}
+	now := timeFunc().Unix()
+
+	tcs := map[string]struct {
+		token    string
+		key      interface{}
+		reason   Reason
+		sentinel error
+	}{
+		"malformed": {
+			token:    "token",
+			key:      secret,
+			reason:   ReasonMalformed,
+			sentinel: ErrTokenMalformed,
+		},
+		"malformed segment": {
+			token:    "eyJhbGciOiJIUzI1NiJ9.!!!.sig",
+			key:      secret,
+			reason:   ReasonMalformed,
+			sentinel: ErrTokenMalformed,
+		},
+		"unsupported alg": {
+			token:    "eyJhbGciOiJub25lIn0.eyJpc3MiOiJpc3MifQ.",
+			key:      secret,
+			reason:   ReasonUnsupportedAlg,
+			sentinel: ErrUnsupportedAlg,
+		},
+		"signature": {
+			token:    tokenStr,
+			key:      []byte("other"),
+			reason:   ReasonSignature,
+			sentinel: ErrSignature,
+		},
+		"no iat": {
+			token:    sign(RawClaims{"exp": now + 60}),
+			key:      secret,
+			reason:   ReasonMalformed,
+			sentinel: ErrTokenIat,
+		},
+		"string exp": {
+			token:    sign(RawClaims{"iat": now, "exp": "tomorrow"}),
+			key:      secret,
+			reason:   ReasonMalformed,
+			sentinel: ErrTokenExpire,
+		},
+		"string nbf": {
+			token:    sign(RawClaims{"iat": now, "exp": now + 60, "nbf": "now"}),
+			key:      secret,
+			reason:   ReasonMalformed,
+			sentinel: ErrTokenNotBefore,
+		},
+		"expired": {
+			token:    sign(RawClaims{"iat": now - 120, "exp": now - 60}),
+			key:      secret,
+			reason:   ReasonExpired,
+			sentinel: ErrTokenExpire,
+		},
+		"key type": {
+			token:    tokenStr,
+			key:      rsaKey,
+			reason:   ReasonKeyType,
+			sentinel: ErrKeyType,
+		},
+	}
+
+	for name, tc := range tcs {
+		_, err := DecodeToken(state, tc.token, tc.key)
+		var verr *ValidationError
+		if !errors.As(err, &verr) {
+			t.Fatalf("ValidationError must be returned in %s: given:%#v", name, err)
+		}
+		if verr.Reason != tc.reason {
+			t.Errorf("Unexpected reason: %s: expect:%s, given:%s", name, tc.reason, verr.Reason)
+		}
+		if !errors.Is(err, tc.sentinel) {
+			t.Errorf("Unexpected error: %s: expect:%#v, given:%#v", name, tc.sentinel, err)
+		}
+		if !errors.Is(err, &ValidationError{Reason: tc.reason}) {
+			t.Errorf("Unexpected error: %s: reason %s must be matched", name, tc.reason)
+		}
+	}
+}
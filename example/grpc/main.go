@@ -40,7 +40,11 @@ func main() {
 	fmt.Println(tokenStr)
 
 	// verify and decode token struct from token string
-	jwt, err := mtoken_grpc.DecodeToken(ctx, tokenStr, pubKey)
+	verifier, err := mtoken.NewVerifier(pubKey, mtoken.WithExpectedIssuers("kokukuma"))
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	jwt, err := mtoken_grpc.DecodeToken(ctx, tokenStr, verifier)
 	if err != nil {
 		log.Fatalf("%v", err)
 	}
@@ -1,8 +1,9 @@
 package main
 
 import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
 	"crypto/rand"
-	"crypto/rsa"
 	"crypto/tls"
 	"crypto/x509"
 	"fmt"
@@ -19,8 +20,14 @@ func main() {
 	// sample private/public key
 	privKey, pubKey := creteSampleKey()
 
+	verifier, err := mtoken.NewVerifier(pubKey)
+	if err != nil {
+		log.Fatalf("%s", err)
+	}
+
 	// server
-	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
 		claims := mtoken.RawClaims{
 			"kid": "kokukuma",
 		}
@@ -30,7 +37,16 @@ func main() {
 			log.Println(err)
 		}
 		w.Write([]byte(tokenStr))
-	}))
+	})
+	mux.HandleFunc("/resource", func(w http.ResponseWriter, r *http.Request) {
+		jwt, err := mtoken_http.DecodeRequest(r, verifier)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprintln(w, jwt)
+	})
+	server := httptest.NewUnstartedServer(mux)
 	server.TLS = getTLSServerConfig()
 	server.StartTLS()
 	defer server.Close()
@@ -41,7 +57,7 @@ func main() {
 			TLSClientConfig: getTLSClientConfig(),
 		},
 	}
-	resp, err := client.Get(server.URL)
+	resp, err := client.Get(server.URL + "/token")
 	if err != nil {
 		log.Fatalf("Failed to get URL: %v", err)
 	}
@@ -50,11 +66,18 @@ func main() {
 	tokenStr := string(b)
 	fmt.Println(tokenStr)
 
-	jwt, err := mtoken_http.DecodeToken(resp, tokenStr, pubKey)
+	req, err := http.NewRequest(http.MethodGet, server.URL+"/resource", nil)
 	if err != nil {
 		log.Fatalf("%s", err)
 	}
-	fmt.Println(jwt)
+	mtoken_http.AddTokenToRequest(req, tokenStr)
+	resp, err = client.Do(req)
+	if err != nil {
+		log.Fatalf("Failed to get URL: %v", err)
+	}
+	defer resp.Body.Close()
+	b, _ = ioutil.ReadAll(resp.Body)
+	fmt.Println(resp.Status, string(b))
 }
 
 func getTLSServerConfig() *tls.Config {
@@ -96,16 +119,11 @@ func getCertPool() *x509.CertPool {
 	return certPool
 }
 
-func creteSampleKey() (*rsa.PrivateKey, *rsa.PublicKey) {
-	privKey, err := rsa.GenerateKey(rand.Reader, 1024)
+func creteSampleKey() (*ecdsa.PrivateKey, *ecdsa.PublicKey) {
+	privKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
 	if err != nil {
 		log.Fatalf("%v", err)
 	}
-	p := privKey.Public()
-	pubKey, ok := p.(*rsa.PublicKey)
-	if !ok {
-		log.Fatalf("failed to convert rsa")
-	}
-	return privKey, pubKey
+	return privKey, &privKey.PublicKey
 
 }
@@ -0,0 +1,288 @@
+package mtoken
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"io/ioutil"
+	"sync"
+	"time"
+)
+
+const defaultPollInterval = 10 * time.Second
+
+// FileKeySource is SigningKeySource and KeySource which reads the keys from files.
+// The files are read again when their contents change, so that the keys
+// mounted from a secrets volume can be updated in place. The directories of
+// the files are watched on Linux by inotify. The files are also polled,
+// which is the fallback on the other platforms or when watching fails.
+// The new keys are validated before they replace the current keys. If reloading
+// fails, the error is reported and the last good keys are kept.
+type FileKeySource struct {
+	privatePath string
+	publicPath  string
+	password    []byte
+	interval    time.Duration
+	watch       bool
+	onReload    func()
+	onError     func(error)
+
+	reloadMu sync.Mutex
+
+	mu      sync.RWMutex
+	keys    *fileKeys
+	private []byte
+	public  []byte
+
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+type fileKeys struct {
+	kid        string
+	privateKey interface{}
+	publicKey  interface{}
+	cert       *tls.Certificate
+}
+
+// FileKeySourceOption configures FileKeySource.
+type FileKeySourceOption func(*FileKeySource)
+
+// WithPollInterval sets how often the files are checked. The default is ten seconds.
+func WithPollInterval(interval time.Duration) FileKeySourceOption {
+	return func(s *FileKeySource) {
+		s.interval = interval
+	}
+}
+
+// WithFileWatch sets whether the files are watched. The default is true.
+// The files are polled even if it is false.
+func WithFileWatch(watch bool) FileKeySourceOption {
+	return func(s *FileKeySource) {
+		s.watch = watch
+	}
+}
+
+// WithKeyPassword sets the password of the encrypted private key.
+func WithKeyPassword(password []byte) FileKeySourceOption {
+	return func(s *FileKeySource) {
+		s.password = password
+	}
+}
+
+// WithReloadHook sets the function called after the keys are reloaded.
+func WithReloadHook(hook func()) FileKeySourceOption {
+	return func(s *FileKeySource) {
+		s.onReload = hook
+	}
+}
+
+// WithReloadErrorHook sets the function called when reloading failed.
+// The last good keys are kept in that case.
+func WithReloadErrorHook(hook func(error)) FileKeySourceOption {
+	return func(s *FileKeySource) {
+		s.onError = hook
+	}
+}
+
+// NewFileKeySource creates FileKeySource and starts watching the files until Close is called.
+// privatePath is the private key used by Issuer, and publicPath is the public key
+// or the certificate chain used by Verifier. Either of them can be empty.
+// If both are given, they must be a key pair. If publicPath is empty,
+// the public key is derived from the private key.
+func NewFileKeySource(privatePath, publicPath string, opts ...FileKeySourceOption) (*FileKeySource, error) {
+	if privatePath == "" && publicPath == "" {
+		return nil, errors.New("key file is not specified")
+	}
+
+	s := &FileKeySource{
+		privatePath: privatePath,
+		publicPath:  publicPath,
+		interval:    defaultPollInterval,
+		watch:       true,
+		stop:        make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.interval <= 0 {
+		return nil, errors.New("poll interval must be positive")
+	}
+
+	if err := s.Reload(); err != nil {
+		return nil, err
+	}
+
+	// events is nil and never ready if the files are not watched.
+	var events <-chan struct{}
+	stopWatch := func() {}
+	if s.watch {
+		if ch, stop, err := watchFiles(privatePath, publicPath); err == nil {
+			events, stopWatch = ch, stop
+		}
+	}
+	go s.run(events, stopWatch)
+	return s, nil
+}
+
+// SigningKey returns kid and the current private key.
+func (s *FileKeySource) SigningKey() (string, interface{}, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.keys.privateKey == nil {
+		return "", nil, ErrKeyPair
+	}
+	return s.keys.kid, s.keys.privateKey, nil
+}
+
+// LookupKey returns the current public key if kid is its JWK thumbprint or empty.
+func (s *FileKeySource) LookupKey(kid string) (interface{}, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if kid != "" && kid != s.keys.kid {
+		return nil, ErrKeyNotFound
+	}
+	return s.keys.publicKey, nil
+}
+
+// GetCertificate returns the current certificate chain and private key.
+// It can be set to tls.Config.GetCertificate.
+func (s *FileKeySource) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.keys.cert == nil {
+		return nil, errors.New("certificate and private key are not loaded")
+	}
+	return s.keys.cert, nil
+}
+
+// GetClientCertificate returns the current certificate chain and private key.
+// It can be set to tls.Config.GetClientCertificate.
+func (s *FileKeySource) GetClientCertificate(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	return s.GetCertificate(nil)
+}
+
+// Reload reads the files and replaces the keys if they are changed.
+func (s *FileKeySource) Reload() error {
+	s.reloadMu.Lock()
+	defer s.reloadMu.Unlock()
+
+	private, err := readKeyFile(s.privatePath)
+	if err != nil {
+		return err
+	}
+	public, err := readKeyFile(s.publicPath)
+	if err != nil {
+		return err
+	}
+
+	s.mu.RLock()
+	unchanged := s.keys != nil && bytes.Equal(private, s.private) && bytes.Equal(public, s.public)
+	s.mu.RUnlock()
+	if unchanged {
+		return nil
+	}
+
+	keys, err := s.parse(private, public)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.keys = keys
+	s.private = private
+	s.public = public
+	s.mu.Unlock()
+
+	if s.onReload != nil {
+		s.onReload()
+	}
+	return nil
+}
+
+// Close stops watching the files.
+func (s *FileKeySource) Close() {
+	s.stopOnce.Do(func() {
+		close(s.stop)
+	})
+}
+
+func (s *FileKeySource) run(events <-chan struct{}, stopWatch func()) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	defer stopWatch()
+
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+		case <-events:
+		}
+		if err := s.Reload(); err != nil && s.onError != nil {
+			s.onError(err)
+		}
+	}
+}
+
+// parse validates the contents of the files.
+func (s *FileKeySource) parse(private, public []byte) (*fileKeys, error) {
+	keys := &fileKeys{}
+
+	var privateJWK *JWK
+	if private != nil {
+		key, err := GetPrivateKeyWithPassword(private, s.password)
+		if err != nil {
+			return nil, err
+		}
+		if privateJWK, err = NewJWK(key); err != nil {
+			return nil, err
+		}
+		keys.privateKey = key
+		keys.publicKey = privateJWK.Key
+		keys.kid = privateJWK.KeyID
+	}
+
+	if public != nil {
+		key, err := GetPublicKey(public)
+		if err != nil {
+			return nil, err
+		}
+		jwk, err := NewJWK(key)
+		if err != nil {
+			return nil, err
+		}
+		if privateJWK != nil && privateJWK.KeyID != jwk.KeyID {
+			return nil, ErrKeyPair
+		}
+		keys.publicKey = key
+		keys.kid = jwk.KeyID
+
+		if keys.privateKey != nil {
+			certs, err := GetCertificates(public)
+			if err == nil {
+				keys.cert = newTLSCertificate(certs, keys.privateKey)
+			}
+		}
+	}
+	return keys, nil
+}
+
+func newTLSCertificate(certs []*x509.Certificate, key interface{}) *tls.Certificate {
+	cert := &tls.Certificate{
+		PrivateKey: key,
+		Leaf:       certs[0],
+	}
+	for _, c := range certs {
+		cert.Certificate = append(cert.Certificate, c.Raw)
+	}
+	return cert
+}
+
+func readKeyFile(path string) ([]byte, error) {
+	if path == "" {
+		return nil, nil
+	}
+	return ioutil.ReadFile(path)
+}
@@ -0,0 +1,256 @@
+package mtoken
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeKeyFiles(t *testing.T, dir string, key *ecdsa.PrivateKey, private, public bool) {
+	if private {
+		der, err := x509.MarshalPKCS8PrivateKey(key)
+		if err != nil {
+			t.Fatalf("Unexpected error occur: expect:%#v", err)
+		}
+		b := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+		if err := ioutil.WriteFile(filepath.Join(dir, "key.pem"), b, 0600); err != nil {
+			t.Fatalf("Unexpected error occur: expect:%#v", err)
+		}
+	}
+	if public {
+		der, err := x509.MarshalPKIXPublicKey(key.Public())
+		if err != nil {
+			t.Fatalf("Unexpected error occur: expect:%#v", err)
+		}
+		b := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+		if err := ioutil.WriteFile(filepath.Join(dir, "pub.pem"), b, 0600); err != nil {
+			t.Fatalf("Unexpected error occur: expect:%#v", err)
+		}
+	}
+}
+
+func TestFileKeySourceReload(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mtoken")
+	if err != nil {
+		t.Fatalf("Unexpected error occur: expect:%#v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	key1, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	key2, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	writeKeyFiles(t, dir, key1, true, true)
+
+	var reloadErr error
+	s, err := NewFileKeySource(filepath.Join(dir, "key.pem"), filepath.Join(dir, "pub.pem"),
+		WithPollInterval(time.Hour),
+		WithFileWatch(false),
+		WithReloadErrorHook(func(err error) { reloadErr = err }),
+	)
+	if err != nil {
+		t.Fatalf("Unexpected error occur: expect:%#v", err)
+	}
+	defer s.Close()
+
+	timeFunc = func() time.Time {
+		return time.Unix(1521644867, 0)
+	}
+	issuer, err := NewIssuer(s)
+	if err != nil {
+		t.Fatalf("Unexpected error occur: expect:%#v", err)
+	}
+	verifier, err := NewVerifier(s)
+	if err != nil {
+		t.Fatalf("Unexpected error occur: expect:%#v", err)
+	}
+
+	state := getTLSState()
+	token1, err := issuer.IssueToken(state, RawClaims{"iss": "iss"})
+	if err != nil {
+		t.Fatalf("Unexpected error occur: expect:%#v", err)
+	}
+	if _, err := verifier.Verify(state, token1); err != nil {
+		t.Fatalf("Unexpected error occur: expect:%#v", err)
+	}
+
+	// the private key is updated before the public key.
+	writeKeyFiles(t, dir, key2, true, false)
+	if err := s.Reload(); err != ErrKeyPair {
+		t.Fatalf("Unexpected error occur: expect:%#v, given:%#v", ErrKeyPair, err)
+	}
+	if _, key, _ := s.SigningKey(); key.(*ecdsa.PrivateKey).D.Cmp(key1.D) != 0 {
+		t.Errorf("Last good key must be kept")
+	}
+
+	writeKeyFiles(t, dir, key2, false, true)
+	if err := s.Reload(); err != nil {
+		t.Fatalf("Unexpected error occur: expect:%#v", err)
+	}
+	token2, err := issuer.IssueToken(state, RawClaims{"iss": "iss"})
+	if err != nil {
+		t.Fatalf("Unexpected error occur: expect:%#v", err)
+	}
+	if _, err := verifier.Verify(state, token2); err != nil {
+		t.Fatalf("Unexpected error occur: expect:%#v", err)
+	}
+	if _, err := verifier.Verify(state, token1); err == nil {
+		t.Errorf("Token signed by the old key must not be verified")
+	}
+	if reloadErr != nil {
+		t.Errorf("Error hook must not be called by Reload: %#v", reloadErr)
+	}
+}
+
+func TestFileKeySourcePolling(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mtoken")
+	if err != nil {
+		t.Fatalf("Unexpected error occur: expect:%#v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	key1, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	key2, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	writeKeyFiles(t, dir, key1, true, false)
+
+	reloaded := make(chan struct{}, 1)
+	failed := make(chan error, 1)
+	s, err := NewFileKeySource(filepath.Join(dir, "key.pem"), "",
+		WithPollInterval(10*time.Millisecond),
+		WithReloadHook(func() { reloaded <- struct{}{} }),
+		WithReloadErrorHook(func(err error) {
+			select {
+			case failed <- err:
+			default:
+			}
+		}),
+	)
+	if err != nil {
+		t.Fatalf("Unexpected error occur: expect:%#v", err)
+	}
+	defer s.Close()
+	<-reloaded
+
+	if err := ioutil.WriteFile(filepath.Join(dir, "key.pem"), []byte("broken"), 0600); err != nil {
+		t.Fatalf("Unexpected error occur: expect:%#v", err)
+	}
+	select {
+	case <-failed:
+	case <-time.After(time.Second):
+		t.Fatalf("Error hook must be called")
+	}
+	if _, key, _ := s.SigningKey(); key.(*ecdsa.PrivateKey).D.Cmp(key1.D) != 0 {
+		t.Errorf("Last good key must be kept")
+	}
+
+	writeKeyFiles(t, dir, key2, true, false)
+	select {
+	case <-reloaded:
+	case <-time.After(time.Second):
+		t.Fatalf("Key must be reloaded")
+	}
+	if _, key, _ := s.SigningKey(); key.(*ecdsa.PrivateKey).D.Cmp(key2.D) != 0 {
+		t.Errorf("Key must be updated")
+	}
+	if pub, err := s.LookupKey(""); err != nil || pub == nil {
+		t.Errorf("Public key must be derived from private key: %#v", err)
+	}
+}
+
+func TestFileKeySourceWatch(t *testing.T) {
+	if !watchSupported {
+		t.Skip("file watching is not supported")
+	}
+	dir, err := ioutil.TempDir("", "mtoken")
+	if err != nil {
+		t.Fatalf("Unexpected error occur: expect:%#v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	key1, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	key2, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	key3, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	writeKeyFiles(t, dir, key1, true, false)
+
+	// The files are not polled within the test.
+	reloaded := make(chan struct{}, 1)
+	s, err := NewFileKeySource(filepath.Join(dir, "key.pem"), "",
+		WithPollInterval(time.Hour),
+		WithReloadHook(func() {
+			select {
+			case reloaded <- struct{}{}:
+			default:
+			}
+		}),
+	)
+	if err != nil {
+		t.Fatalf("Unexpected error occur: expect:%#v", err)
+	}
+	defer s.Close()
+
+	waitKey := func(name string, expected *ecdsa.PrivateKey) {
+		deadline := time.After(5 * time.Second)
+		for {
+			if _, key, _ := s.SigningKey(); key.(*ecdsa.PrivateKey).D.Cmp(expected.D) == 0 {
+				return
+			}
+			select {
+			case <-reloaded:
+			case <-deadline:
+				t.Fatalf("Key must be reloaded by the watcher: %s", name)
+			}
+		}
+	}
+
+	// The file is written in place.
+	writeKeyFiles(t, dir, key2, true, false)
+	waitKey("write", key2)
+
+	// The file is replaced by rename like a secrets volume.
+	tmp, err := ioutil.TempDir("", "mtoken")
+	if err != nil {
+		t.Fatalf("Unexpected error occur: expect:%#v", err)
+	}
+	defer os.RemoveAll(tmp)
+	writeKeyFiles(t, tmp, key3, true, false)
+	if err := os.Rename(filepath.Join(tmp, "key.pem"), filepath.Join(dir, "key.pem")); err != nil {
+		t.Fatalf("Unexpected error occur: expect:%#v", err)
+	}
+	waitKey("rename", key3)
+}
+
+func TestFileKeySourceCertificate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mtoken")
+	if err != nil {
+		t.Fatalf("Unexpected error occur: expect:%#v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	key, err := getECDSAPrivateKey()
+	if err != nil {
+		t.Fatalf("Unexpected error occur: expect:%#v", err)
+	}
+	writeKeyFiles(t, dir, key.(*ecdsa.PrivateKey), true, false)
+	if err := ioutil.WriteFile(filepath.Join(dir, "cert.pem"), getCertificatePEM(t, key, "client"), 0600); err != nil {
+		t.Fatalf("Unexpected error occur: expect:%#v", err)
+	}
+
+	s, err := NewFileKeySource(filepath.Join(dir, "key.pem"), filepath.Join(dir, "cert.pem"))
+	if err != nil {
+		t.Fatalf("Unexpected error occur: expect:%#v", err)
+	}
+	defer s.Close()
+
+	cert, err := s.GetClientCertificate(nil)
+	if err != nil {
+		t.Fatalf("Unexpected error occur: expect:%#v", err)
+	}
+	if cert.Leaf.Subject.CommonName != "client" || len(cert.Certificate) != 1 {
+		t.Errorf("Unexpected certificate: %#v", cert.Leaf.Subject)
+	}
+}
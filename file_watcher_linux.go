@@ -0,0 +1,63 @@
+//go:build linux
+// +build linux
+
+package mtoken
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+// watchSupported reports whether the files are watched on this platform.
+const watchSupported = true
+
+// watchEvents of the directory which tell the file may be changed.
+// The directory is watched instead of the file, because the file of
+// a secrets volume is replaced by renaming the symlink.
+const watchEvents = syscall.IN_CLOSE_WRITE | syscall.IN_CREATE | syscall.IN_MOVED_TO |
+	syscall.IN_DELETE | syscall.IN_MOVED_FROM | syscall.IN_ATTRIB
+
+// watchFiles notifies when the directories of the paths are changed.
+// The events are not distinguished, because Reload reads the files and
+// ignores unchanged contents anyway. The returned function stops watching.
+func watchFiles(paths ...string) (<-chan struct{}, func(), error) {
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC | syscall.IN_NONBLOCK)
+	if err != nil {
+		return nil, nil, err
+	}
+	// The non-blocking file is read by the runtime poller, so that Close
+	// wakes the blocked Read.
+	f := os.NewFile(uintptr(fd), "inotify")
+
+	watched := map[string]bool{}
+	for _, path := range paths {
+		if path == "" {
+			continue
+		}
+		dir := filepath.Dir(path)
+		if watched[dir] {
+			continue
+		}
+		if _, err := syscall.InotifyAddWatch(fd, dir, watchEvents); err != nil {
+			f.Close()
+			return nil, nil, err
+		}
+		watched[dir] = true
+	}
+
+	events := make(chan struct{}, 1)
+	go func() {
+		buf := make([]byte, 4096)
+		for {
+			if _, err := f.Read(buf); err != nil {
+				return
+			}
+			select {
+			case events <- struct{}{}:
+			default:
+			}
+		}
+	}()
+	return events, func() { f.Close() }, nil
+}
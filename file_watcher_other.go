@@ -0,0 +1,14 @@
+//go:build !linux
+// +build !linux
+
+package mtoken
+
+import "errors"
+
+// watchSupported reports whether the files are watched on this platform.
+const watchSupported = false
+
+// watchFiles is not supported, so the files are only polled.
+func watchFiles(paths ...string) (<-chan struct{}, func(), error) {
+	return nil, nil, errors.New("file watching is not supported")
+}
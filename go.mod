@@ -6,5 +6,7 @@ require (
 	github.com/davecgh/go-spew v1.1.1
 	github.com/json-iterator/go v1.1.8
 	github.com/theshadow/mock-conn v0.0.0-20160218183754-909cee22179a
+	golang.org/x/crypto v0.0.0-20190308221718-c2843e01d9a2
 	google.golang.org/grpc v1.24.0
+	gopkg.in/yaml.v2 v2.4.0
 )
@@ -2,9 +2,8 @@ package grpc
 
 import (
 	"context"
-	"fmt"
-	"strings"
 
+	mtls_token "github.com/kokukuma/mtls-token"
 	"google.golang.org/grpc/metadata"
 )
 
@@ -15,26 +14,23 @@ const (
 )
 
 // GetTokenFromContext returns token on Authorization header.
+// The scheme must be Bearer.
 func GetTokenFromContext(ctx context.Context) (string, error) {
-	md, ok := metadata.FromIncomingContext(ctx)
-	if !ok {
-		return "", fmt.Errorf("no client auth token")
+	cred, err := GetCredentialFromContext(ctx, mtls_token.DefaultCredentialExtractor)
+	if err != nil {
+		return "", err
 	}
+	return cred.Token, nil
+}
 
-	values, ok := md[clientAuthKey]
+// GetCredentialFromContext returns the credential on Authorization header
+// parsed by extractor.
+func GetCredentialFromContext(ctx context.Context, extractor *mtls_token.CredentialExtractor) (*mtls_token.Credential, error) {
+	md, ok := metadata.FromIncomingContext(ctx)
 	if !ok {
-		return "", fmt.Errorf("no client auth token")
-	}
-
-	parts := strings.SplitN(values[0], " ", 2)
-	if len(parts) != 2 {
-		return "", fmt.Errorf("invalid client auth format")
-	}
-
-	if strings.ToUpper(parts[0]) != "Bearer" {
-		return "", fmt.Errorf("token_type should be Bearer: %q", parts[0])
+		return nil, mtls_token.ErrNoCredential
 	}
-	return parts[1], nil
+	return extractor.Extract(md[clientAuthKey])
 }
 
 // AddTokenToContext returns context that has token.
@@ -0,0 +1,58 @@
+package grpc
+
+import (
+	"context"
+	"testing"
+
+	"google.golang.org/grpc/metadata"
+)
+
+func TestGetTokenFromContext(t *testing.T) {
+	tcs := map[string]struct {
+		ctx   context.Context
+		token string
+	}{
+		"bearer": {
+			ctx:   metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer token")),
+			token: "token",
+		},
+		"upper case": {
+			ctx:   metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "BEARER token")),
+			token: "token",
+		},
+		"added to context": {
+			ctx:   metadata.NewIncomingContext(context.Background(), mdFromOutgoing(AddTokenToContext(context.Background(), "token"))),
+			token: "token",
+		},
+		"no metadata": {
+			ctx: context.Background(),
+		},
+		"duplicate": {
+			ctx: metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer a", "authorization", "Bearer b")),
+		},
+		"other scheme": {
+			ctx: metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Basic dXNlcjpwYXNz")),
+		},
+	}
+
+	for name, tc := range tcs {
+		token, err := GetTokenFromContext(tc.ctx)
+		if tc.token == "" {
+			if err == nil {
+				t.Errorf("Should be error occur in %s", name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("Unexpected error occur in %s: expect:%#v", name, err)
+		}
+		if token != tc.token {
+			t.Errorf("Unexpected token: %s: expect:%#v, given:%#v", name, tc.token, token)
+		}
+	}
+}
+
+func mdFromOutgoing(ctx context.Context) metadata.MD {
+	md, _ := metadata.FromOutgoingContext(ctx)
+	return md
+}
@@ -0,0 +1,46 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+
+	mtls_token "github.com/kokukuma/mtls-token"
+	"google.golang.org/grpc/credentials"
+)
+
+// PerRPCCredentials sets the token on authorization metadata of every RPC.
+// It is used with grpc.WithPerRPCCredentials and the mTLS transport credentials.
+type PerRPCCredentials struct {
+	source mtls_token.TokenSource
+}
+
+var _ credentials.PerRPCCredentials = (*PerRPCCredentials)(nil)
+
+// NewPerRPCCredentials creates PerRPCCredentials.
+// The token is cached and refreshed before it expires,
+// unless source is already *mtls_token.CachedTokenSource.
+func NewPerRPCCredentials(source mtls_token.TokenSource) (*PerRPCCredentials, error) {
+	if source == nil {
+		return nil, errors.New("token source is nil")
+	}
+	if _, ok := source.(*mtls_token.CachedTokenSource); !ok {
+		source = mtls_token.NewCachedTokenSource(source)
+	}
+	return &PerRPCCredentials{source: source}, nil
+}
+
+// GetRequestMetadata returns authorization metadata.
+func (c *PerRPCCredentials) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	token, err := c.source.Token(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]string{
+		clientAuthKey: "Bearer " + token,
+	}, nil
+}
+
+// RequireTransportSecurity returns true because the token is bound to the client certificate.
+func (c *PerRPCCredentials) RequireTransportSecurity() bool {
+	return true
+}
@@ -0,0 +1,50 @@
+package grpc
+
+import (
+	"context"
+	"testing"
+
+	mtls_token "github.com/kokukuma/mtls-token"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestPerRPCCredentials(t *testing.T) {
+	issued := 0
+	source := mtls_token.TokenSourceFunc(func(ctx context.Context) (string, error) {
+		issued++
+		state, err := getCSFromContext(tlsContext("client"))
+		if err != nil {
+			return "", err
+		}
+		return mtls_token.IssueToken(state, []byte("secret"), mtls_token.RawClaims{})
+	})
+
+	creds, err := NewPerRPCCredentials(source)
+	if err != nil {
+		t.Fatalf("Unexpected error occur: expect:%#v", err)
+	}
+	if !creds.RequireTransportSecurity() {
+		t.Errorf("Transport security must be required")
+	}
+
+	for i := 0; i < 2; i++ {
+		md, err := creds.GetRequestMetadata(context.Background())
+		if err != nil {
+			t.Fatalf("Unexpected error occur: expect:%#v", err)
+		}
+
+		// the server reads the metadata.
+		ctx := metadata.NewIncomingContext(tlsContext("client"), metadata.New(md))
+		token, err := GetTokenFromContext(ctx)
+		if err != nil {
+			t.Fatalf("Unexpected error occur: expect:%#v", err)
+		}
+		verifier, _ := mtls_token.NewVerifier([]byte("secret"))
+		if _, err := DecodeToken(ctx, token, verifier); err != nil {
+			t.Errorf("Unexpected error occur: %#v", err)
+		}
+	}
+	if issued != 1 {
+		t.Errorf("Token must be cached: issued:%d", issued)
+	}
+}
@@ -2,7 +2,6 @@ package grpc
 
 import (
 	"context"
-	"crypto/rsa"
 	"crypto/tls"
 	"errors"
 
@@ -12,21 +11,24 @@ import (
 )
 
 // IssueToken creates access token.
-func IssueToken(ctx context.Context, privateKey *rsa.PrivateKey, claims mtls_token.RawClaims) (string, error) {
+func IssueToken(ctx context.Context, privateKey interface{}, claims mtls_token.RawClaims, opts ...mtls_token.IssuerOption) (string, error) {
 	state, err := getCSFromContext(ctx)
 	if err != nil {
 		return "", err
 	}
-	return mtls_token.IssueToken(state, privateKey, claims)
+	return mtls_token.IssueToken(state, privateKey, claims, opts...)
 }
 
 // DecodeToken decode token
-func DecodeToken(ctx context.Context, payload string, publicKey *rsa.PublicKey) (*mtls_token.JWT, error) {
+func DecodeToken(ctx context.Context, payload string, verifier *mtls_token.Verifier) (*mtls_token.JWT, error) {
+	if verifier == nil {
+		return nil, errors.New("verifier is nil")
+	}
 	state, err := getCSFromContext(ctx)
 	if err != nil {
 		return nil, err
 	}
-	return mtls_token.DecodeToken(state, payload, publicKey)
+	return verifier.Verify(state, payload)
 }
 
 func getCSFromContext(ctx context.Context) (*tls.ConnectionState, error) {
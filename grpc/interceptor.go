@@ -0,0 +1,145 @@
+package grpc
+
+import (
+	"context"
+
+	mtls_token "github.com/kokukuma/mtls-token"
+	google_grpc "google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type contextKey struct{}
+
+type credentialContextKey struct{}
+
+// JWTFromContext returns the token verified by the interceptor.
+func JWTFromContext(ctx context.Context) (*mtls_token.JWT, bool) {
+	jwt, ok := ctx.Value(contextKey{}).(*mtls_token.JWT)
+	return jwt, ok
+}
+
+// ContextWithJWT returns context that has the token.
+func ContextWithJWT(ctx context.Context, jwt *mtls_token.JWT) context.Context {
+	return context.WithValue(ctx, contextKey{}, jwt)
+}
+
+// CredentialFromContext returns the credential of the token verified by the interceptor.
+// The handler which accepts DPoP validates the proof when Scheme is DPoP.
+func CredentialFromContext(ctx context.Context) (*mtls_token.Credential, bool) {
+	cred, ok := ctx.Value(credentialContextKey{}).(*mtls_token.Credential)
+	return cred, ok
+}
+
+// ContextWithCredential returns context that has the credential.
+func ContextWithCredential(ctx context.Context, cred *mtls_token.Credential) context.Context {
+	return context.WithValue(ctx, credentialContextKey{}, cred)
+}
+
+type interceptor struct {
+	verifier    *mtls_token.Verifier
+	credentials *mtls_token.CredentialExtractor
+	exempt      map[string]bool
+	scopes      []string
+}
+
+// InterceptorOption configures the interceptors.
+type InterceptorOption func(*interceptor)
+
+// WithExemptMethods sets the full method names which are called without the token,
+// e.g. "/grpc.health.v1.Health/Check".
+func WithExemptMethods(methods ...string) InterceptorOption {
+	return func(i *interceptor) {
+		for _, m := range methods {
+			i.exempt[m] = true
+		}
+	}
+}
+
+// WithRequiredScopes sets the scopes which the token must have.
+func WithRequiredScopes(scopes ...string) InterceptorOption {
+	return func(i *interceptor) {
+		i.scopes = append(i.scopes, scopes...)
+	}
+}
+
+// WithCredentialExtractor sets the accepted schemes of authorization metadata.
+// The default is Bearer. The DPoP proof is not validated by the interceptors.
+func WithCredentialExtractor(extractor *mtls_token.CredentialExtractor) InterceptorOption {
+	return func(i *interceptor) {
+		i.credentials = extractor
+	}
+}
+
+func newInterceptor(verifier *mtls_token.Verifier, opts []InterceptorOption) *interceptor {
+	if verifier == nil {
+		panic("verifier is nil")
+	}
+	i := &interceptor{
+		verifier:    verifier,
+		credentials: mtls_token.DefaultCredentialExtractor,
+		exempt:      map[string]bool{},
+	}
+	for _, opt := range opts {
+		opt(i)
+	}
+	return i
+}
+
+// UnaryServerInterceptor verifies the token on authorization metadata before the handler is called.
+// The verified token can be gotten by JWTFromContext, and its scheme by CredentialFromContext.
+// The failure is returned as codes.Unauthenticated, or codes.PermissionDenied
+// when the token does not have the required scopes.
+// It panics if verifier is nil.
+func UnaryServerInterceptor(verifier *mtls_token.Verifier, opts ...InterceptorOption) google_grpc.UnaryServerInterceptor {
+	i := newInterceptor(verifier, opts)
+	return func(ctx context.Context, req interface{}, info *google_grpc.UnaryServerInfo, handler google_grpc.UnaryHandler) (interface{}, error) {
+		ctx, err := i.authenticate(ctx, info.FullMethod)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor is the stream version of UnaryServerInterceptor.
+// It panics if verifier is nil.
+func StreamServerInterceptor(verifier *mtls_token.Verifier, opts ...InterceptorOption) google_grpc.StreamServerInterceptor {
+	i := newInterceptor(verifier, opts)
+	return func(srv interface{}, ss google_grpc.ServerStream, info *google_grpc.StreamServerInfo, handler google_grpc.StreamHandler) error {
+		ctx, err := i.authenticate(ss.Context(), info.FullMethod)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &serverStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+func (i *interceptor) authenticate(ctx context.Context, method string) (context.Context, error) {
+	if i.exempt[method] {
+		return ctx, nil
+	}
+
+	cred, err := GetCredentialFromContext(ctx, i.credentials)
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+	jwt, err := DecodeToken(ctx, cred.Token, i.verifier)
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+	if !jwt.Claims().HasScopes(i.scopes...) {
+		return nil, status.Error(codes.PermissionDenied, "insufficient scope")
+	}
+	return ContextWithCredential(ContextWithJWT(ctx, jwt), cred), nil
+}
+
+// serverStream replaces the context of the stream.
+type serverStream struct {
+	google_grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
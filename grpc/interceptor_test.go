@@ -0,0 +1,144 @@
+package grpc
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"net"
+	"testing"
+
+	mtls_token "github.com/kokukuma/mtls-token"
+	google_grpc "google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+func tlsContext(raw string) context.Context {
+	return peer.NewContext(context.Background(), &peer.Peer{
+		Addr: &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 443},
+		AuthInfo: credentials.TLSInfo{
+			State: tls.ConnectionState{
+				PeerCertificates: []*x509.Certificate{{Raw: []byte(raw)}},
+			},
+		},
+	})
+}
+
+type testStream struct {
+	google_grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *testStream) Context() context.Context {
+	return s.ctx
+}
+
+func TestInterceptors(t *testing.T) {
+	secret := []byte("secret")
+	issue := func(rc mtls_token.RawClaims) string {
+		state := &tls.ConnectionState{PeerCertificates: []*x509.Certificate{{Raw: []byte("client")}}}
+		token, err := mtls_token.IssueToken(state, secret, rc)
+		if err != nil {
+			t.Fatalf("Unexpected error occur: expect:%#v", err)
+		}
+		return token
+	}
+	verifier, err := mtls_token.NewVerifier(secret)
+	if err != nil {
+		t.Fatalf("Unexpected error occur: expect:%#v", err)
+	}
+	opts := []InterceptorOption{
+		WithExemptMethods("/grpc.health.v1.Health/Check"),
+		WithRequiredScopes("read"),
+	}
+	unary := UnaryServerInterceptor(verifier, opts...)
+	stream := StreamServerInterceptor(verifier, opts...)
+
+	tcs := map[string]struct {
+		method string
+		cert   string
+		token  string
+		code   codes.Code
+	}{
+		"valid": {
+			method: "/example.Service/Get",
+			cert:   "client",
+			token:  issue(mtls_token.RawClaims{"scope": "read"}),
+			code:   codes.OK,
+		},
+		"exempt": {
+			method: "/grpc.health.v1.Health/Check",
+			cert:   "client",
+			code:   codes.OK,
+		},
+		"no token": {
+			method: "/example.Service/Get",
+			cert:   "client",
+			code:   codes.Unauthenticated,
+		},
+		"other certificate": {
+			method: "/example.Service/Get",
+			cert:   "other",
+			token:  issue(mtls_token.RawClaims{"scope": "read"}),
+			code:   codes.Unauthenticated,
+		},
+		"insufficient scope": {
+			method: "/example.Service/Get",
+			cert:   "client",
+			token:  issue(mtls_token.RawClaims{"scope": "write"}),
+			code:   codes.PermissionDenied,
+		},
+	}
+
+	for name, tc := range tcs {
+		ctx := tlsContext(tc.cert)
+		if tc.token != "" {
+			ctx = metadata.NewIncomingContext(ctx, metadata.Pairs("authorization", "Bearer "+tc.token))
+		}
+		check := func(ctx context.Context) {
+			if _, ok := JWTFromContext(ctx); ok == (tc.token == "") {
+				t.Errorf("Unexpected token in context: %s", name)
+			}
+			if cred, ok := CredentialFromContext(ctx); ok && cred.Scheme != mtls_token.SchemeBearer {
+				t.Errorf("Unexpected scheme in context: %s: %#v", name, cred.Scheme)
+			}
+		}
+
+		_, err := unary(ctx, nil, &google_grpc.UnaryServerInfo{FullMethod: tc.method}, func(ctx context.Context, req interface{}) (interface{}, error) {
+			check(ctx)
+			return nil, nil
+		})
+		if code := status.Code(err); code != tc.code {
+			t.Errorf("Unexpected code of unary: %s: expect:%v, given:%v", name, tc.code, code)
+		}
+
+		err = stream(nil, &testStream{ctx: ctx}, &google_grpc.StreamServerInfo{FullMethod: tc.method}, func(srv interface{}, ss google_grpc.ServerStream) error {
+			check(ss.Context())
+			return nil
+		})
+		if code := status.Code(err); code != tc.code {
+			t.Errorf("Unexpected code of stream: %s: expect:%v, given:%v", name, tc.code, code)
+		}
+	}
+}
+
+func TestInterceptorsNilVerifier(t *testing.T) {
+	tcs := map[string]func(){
+		"unary":  func() { UnaryServerInterceptor(nil) },
+		"stream": func() { StreamServerInterceptor(nil) },
+	}
+
+	for name, f := range tcs {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("Should be panic in %s", name)
+				}
+			}()
+			f()
+		}()
+	}
+}
@@ -1,9 +1,8 @@
 package mtoken
 
 import (
+	"crypto"
 	"crypto/hmac"
-	"crypto/sha256"
-	"errors"
 )
 
 // HS256 represent Signature algorithm.
@@ -17,26 +16,69 @@ func (r HS256) Name() string {
 
 // Sign creates signature
 func (r HS256) Sign(key interface{}, ss string) ([]byte, error) {
-	k, ok := key.([]byte)
-	if !ok {
-		return nil, errors.New("Unexpected key type")
-	}
-	hasher := hmac.New(sha256.New, k)
-	hasher.Write([]byte(ss))
-	return hasher.Sum(nil), nil
+	return signHMAC(crypto.SHA256, key, ss)
 }
 
 // Verify exec verify signature
 func (r HS256) Verify(key interface{}, ss string, sig []byte) error {
+	return verifyHMAC(crypto.SHA256, key, ss, sig)
+}
+
+// HS384 represent Signature algorithm.
+type HS384 struct {
+}
+
+// Name returns alg name.
+func (r HS384) Name() string {
+	return "HS384"
+}
+
+// Sign creates signature
+func (r HS384) Sign(key interface{}, ss string) ([]byte, error) {
+	return signHMAC(crypto.SHA384, key, ss)
+}
+
+// Verify exec verify signature
+func (r HS384) Verify(key interface{}, ss string, sig []byte) error {
+	return verifyHMAC(crypto.SHA384, key, ss, sig)
+}
+
+// HS512 represent Signature algorithm.
+type HS512 struct {
+}
+
+// Name returns alg name.
+func (r HS512) Name() string {
+	return "HS512"
+}
+
+// Sign creates signature
+func (r HS512) Sign(key interface{}, ss string) ([]byte, error) {
+	return signHMAC(crypto.SHA512, key, ss)
+}
+
+// Verify exec verify signature
+func (r HS512) Verify(key interface{}, ss string, sig []byte) error {
+	return verifyHMAC(crypto.SHA512, key, ss, sig)
+}
 
+func signHMAC(hash crypto.Hash, key interface{}, ss string) ([]byte, error) {
 	k, ok := key.([]byte)
 	if !ok {
-		return errors.New("Unexpected key type")
+		return nil, ErrKeyType
 	}
-	hasher := hmac.New(sha256.New, k)
+	hasher := hmac.New(hash.New, k)
 	hasher.Write([]byte(ss))
-	if !hmac.Equal(hasher.Sum(nil), sig) {
-		return errors.New("Failed to verify")
+	return hasher.Sum(nil), nil
+}
+
+func verifyHMAC(hash crypto.Hash, key interface{}, ss string, sig []byte) error {
+	expect, err := signHMAC(hash, key, ss)
+	if err != nil {
+		return err
+	}
+	if !hmac.Equal(expect, sig) {
+		return ErrSignature
 	}
 	return nil
 }
@@ -1,6 +1,7 @@
 package mtoken
 
 import (
+	"encoding/base64"
 	"testing"
 )
 
@@ -53,3 +54,59 @@ func TestHS256SignFailed(t *testing.T) {
 		}
 	}
 }
+
+func TestHSSignVerifyHashes(t *testing.T) {
+	secret := []byte("secret")
+
+	tcs := map[string]struct {
+		method  Method
+		sigSize int
+	}{
+		"HS256": {
+			method:  HS256{},
+			sigSize: 32,
+		},
+		"HS384": {
+			method:  HS384{},
+			sigSize: 48,
+		},
+		"HS512": {
+			method:  HS512{},
+			sigSize: 64,
+		},
+	}
+
+	for name, tc := range tcs {
+		sign, err := tc.method.Sign(secret, "sample")
+		if err != nil {
+			t.Fatalf("Unexpected error occur in %s: expect:%#v", name, err)
+		}
+		if len(sign) != tc.sigSize {
+			t.Errorf("Unexpected signature length in %s: expect:%#v, given:%#v", name, tc.sigSize, len(sign))
+		}
+		if err := tc.method.Verify(secret, "sample", sign); err != nil {
+			t.Fatalf("Unexpected error occur in %s: expect:%#v", name, err)
+		}
+		if err := tc.method.Verify([]byte("other"), "sample", sign); err != ErrSignature {
+			t.Errorf("Unexpected error occur in %s: expect:%#v, given:%#v", name, ErrSignature, err)
+		}
+	}
+}
+
+// RFC 7515 Appendix A.1
+func TestHS256RFC7515(t *testing.T) {
+	key, err := base64.RawURLEncoding.DecodeString("AyM1SysPpbyDfgZld3umj1qzKObwVMkoqQ-EstJQLr_T-1qS0gZH75aKtMN3Yj0iPS4hcgUuTwjAzZr1Z9CAow")
+	if err != nil {
+		t.Fatalf("Unexpected error occur: expect:%#v", err)
+	}
+	signingInput := "eyJ0eXAiOiJKV1QiLA0KICJhbGciOiJIUzI1NiJ9.eyJpc3MiOiJqb2UiLA0KICJleHAiOjEzMDA4MTkzODAsDQogImh0dHA6Ly9leGFtcGxlLmNvbS9pc19yb290Ijp0cnVlfQ"
+	expect := "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+
+	sign, err := HS256{}.Sign(key, signingInput)
+	if err != nil {
+		t.Fatalf("Unexpected error occur: expect:%#v", err)
+	}
+	if actual := base64.RawURLEncoding.EncodeToString(sign); actual != expect {
+		t.Errorf("Unexpected signature: expect:%#v, given:%#v", expect, actual)
+	}
+}
@@ -0,0 +1,258 @@
+package http
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+
+	mtls_token "github.com/kokukuma/mtls-token"
+)
+
+// XFCCHeader is the header where Envoy forwards the client certificate.
+const XFCCHeader = "X-Forwarded-Client-Cert"
+
+// ClientCertificate is the client certificate of the request.
+// Certificates is empty when only the thumbprint is known.
+// Verified is true when the chain is verified by the TLS handshake,
+// or forwarded by the trusted proxy which verified it.
+type ClientCertificate struct {
+	Certificates []*x509.Certificate
+	Thumbprint   string
+	Verified     bool
+}
+
+// CertificateExtractor returns the client certificate of the request.
+type CertificateExtractor interface {
+	ExtractCertificate(req *http.Request) (*ClientCertificate, error)
+}
+
+// CertificateExtractorFunc is the function used as CertificateExtractor.
+type CertificateExtractorFunc func(req *http.Request) (*ClientCertificate, error)
+
+// ExtractCertificate calls f(req).
+func (f CertificateExtractorFunc) ExtractCertificate(req *http.Request) (*ClientCertificate, error) {
+	return f(req)
+}
+
+// TLSExtractor returns the client certificate of the TLS connection.
+var TLSExtractor CertificateExtractor = CertificateExtractorFunc(extractTLS)
+
+func extractTLS(req *http.Request) (*ClientCertificate, error) {
+	if req.TLS == nil {
+		return nil, mtls_token.ErrMutualTLSConnection
+	}
+	certs, verified := req.TLS.PeerCertificates, false
+	if len(req.TLS.VerifiedChains) > 0 && len(req.TLS.VerifiedChains[0]) > 0 {
+		certs, verified = req.TLS.VerifiedChains[0], true
+	}
+	if len(certs) == 0 {
+		return nil, mtls_token.ErrMutualTLSConnection
+	}
+	tp, err := mtls_token.CertificateThumbprint(certs)
+	if err != nil {
+		return nil, err
+	}
+	return &ClientCertificate{Certificates: certs, Thumbprint: tp, Verified: verified}, nil
+}
+
+// proxyExtractor reads the header set by the proxy which terminates TLS.
+// The header is trusted only when the request comes from the proxy.
+type proxyExtractor struct {
+	header  string
+	trusted []*net.IPNet
+	parse   func(string) (*ClientCertificate, error)
+}
+
+// NewXFCCExtractor creates CertificateExtractor for x-forwarded-client-cert of Envoy.
+// Cert and Chain are used if they are forwarded, otherwise Hash is used.
+// trustedProxies are IP addresses or CIDRs of the proxies.
+// https://www.envoyproxy.io/docs/envoy/latest/configuration/http/http_conn_man/headers#x-forwarded-client-cert
+func NewXFCCExtractor(trustedProxies ...string) (CertificateExtractor, error) {
+	return newProxyExtractor(XFCCHeader, parseXFCC, trustedProxies)
+}
+
+// NewEscapedCertExtractor creates CertificateExtractor for the header which has
+// URL-encoded PEM, e.g. $ssl_client_escaped_cert of nginx.
+// trustedProxies are IP addresses or CIDRs of the proxies.
+func NewEscapedCertExtractor(header string, trustedProxies ...string) (CertificateExtractor, error) {
+	if header == "" {
+		return nil, errors.New("header is empty")
+	}
+	return newProxyExtractor(header, parseEscapedCert, trustedProxies)
+}
+
+func newProxyExtractor(header string, parse func(string) (*ClientCertificate, error), trustedProxies []string) (*proxyExtractor, error) {
+	if len(trustedProxies) == 0 {
+		return nil, errors.New("trusted proxy is not specified")
+	}
+	e := &proxyExtractor{header: header, parse: parse}
+	for _, p := range trustedProxies {
+		if !strings.Contains(p, "/") {
+			ip := net.ParseIP(p)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid proxy address: %q", p)
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+			e.trusted = append(e.trusted, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, n, err := net.ParseCIDR(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy address: %q", p)
+		}
+		e.trusted = append(e.trusted, n)
+	}
+	return e, nil
+}
+
+// ExtractCertificate returns the client certificate forwarded by the proxy.
+// The certificate is Verified, so the proxy must forward only the verified one.
+func (e *proxyExtractor) ExtractCertificate(req *http.Request) (*ClientCertificate, error) {
+	if !e.isTrusted(req.RemoteAddr) {
+		return nil, fmt.Errorf("request is not from trusted proxy: %s", req.RemoteAddr)
+	}
+	values := req.Header[http.CanonicalHeaderKey(e.header)]
+	if len(values) == 0 || values[0] == "" {
+		return nil, mtls_token.ErrMutualTLSConnection
+	}
+	if len(values) > 1 {
+		return nil, fmt.Errorf("multiple %s headers", e.header)
+	}
+	cert, err := e.parse(values[0])
+	if err != nil {
+		return nil, err
+	}
+	cert.Verified = true
+	return cert, nil
+}
+
+func (e *proxyExtractor) isTrusted(remoteAddr string) bool {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return false
+	}
+	for _, n := range e.trusted {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseXFCC uses the last element, which is added by the nearest proxy.
+func parseXFCC(value string) (*ClientCertificate, error) {
+	elements := splitQuoted(value, ',')
+	pairs := splitQuoted(elements[len(elements)-1], ';')
+
+	var hash, cert, chain string
+	for _, pair := range pairs {
+		kv := strings.SplitN(pair, "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("invalid %s element: %q", XFCCHeader, pair)
+		}
+		v := unquote(strings.TrimSpace(kv[1]))
+		switch strings.ToLower(strings.TrimSpace(kv[0])) {
+		case "hash":
+			hash = v
+		case "cert":
+			cert = v
+		case "chain":
+			chain = v
+		}
+	}
+
+	var tp string
+	if hash != "" {
+		sum, err := hex.DecodeString(hash)
+		if err != nil || len(sum) != 32 {
+			return nil, fmt.Errorf("invalid %s hash: %q", XFCCHeader, hash)
+		}
+		tp = base64.RawURLEncoding.EncodeToString(sum)
+	}
+
+	// Chain includes the client certificate.
+	if chain != "" {
+		cert = chain
+	}
+	if cert == "" {
+		if tp == "" {
+			return nil, mtls_token.ErrMutualTLSConnection
+		}
+		return &ClientCertificate{Thumbprint: tp}, nil
+	}
+
+	c, err := parseEscapedCert(cert)
+	if err != nil {
+		return nil, err
+	}
+	if tp != "" && tp != c.Thumbprint {
+		return nil, fmt.Errorf("%s hash does not match the certificate", XFCCHeader)
+	}
+	return c, nil
+}
+
+func parseEscapedCert(value string) (*ClientCertificate, error) {
+	pem, err := url.PathUnescape(value)
+	if err != nil {
+		return nil, err
+	}
+	certs, err := mtls_token.GetCertificates([]byte(pem))
+	if err != nil {
+		return nil, err
+	}
+	tp, err := mtls_token.CertificateThumbprint(certs)
+	if err != nil {
+		return nil, err
+	}
+	return &ClientCertificate{Certificates: certs, Thumbprint: tp}, nil
+}
+
+// splitQuoted splits s by sep which is not in the quoted string.
+func splitQuoted(s string, sep byte) []string {
+	var parts []string
+	var quoted, escaped bool
+	start := 0
+	for i := 0; i < len(s); i++ {
+		switch {
+		case escaped:
+			escaped = false
+		case s[i] == '\\' && quoted:
+			escaped = true
+		case s[i] == '"':
+			quoted = !quoted
+		case s[i] == sep && !quoted:
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, s[start:])
+}
+
+func unquote(s string) string {
+	if len(s) < 2 || s[0] != '"' || s[len(s)-1] != '"' {
+		return s
+	}
+	var b bytes.Buffer
+	s = s[1 : len(s)-1]
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) {
+			i++
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
@@ -0,0 +1,289 @@
+package http
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	mtls_token "github.com/kokukuma/mtls-token"
+)
+
+func getCertificate(t *testing.T) *x509.Certificate {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "client"},
+		NotBefore:    time.Unix(1521644867, 0),
+		NotAfter:     time.Unix(1521644867, 0).Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, key.Public(), key)
+	if err != nil {
+		t.Fatalf("Unexpected error occur: expect:%#v", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("Unexpected error occur: expect:%#v", err)
+	}
+	return cert
+}
+
+// getCAChain returns two certificates issued by the same CA, and the CA.
+func getCAChain(t *testing.T) (*x509.Certificate, *x509.Certificate, *x509.Certificate) {
+	caKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	create := func(serial int64, key *ecdsa.PrivateKey, parent *x509.Certificate) *x509.Certificate {
+		tmpl := &x509.Certificate{
+			SerialNumber:          big.NewInt(serial),
+			Subject:               pkix.Name{CommonName: "client"},
+			NotBefore:             time.Unix(1521644867, 0),
+			NotAfter:              time.Unix(1521644867, 0).Add(time.Hour),
+			BasicConstraintsValid: true,
+			IsCA:                  parent == nil,
+		}
+		if parent == nil {
+			tmpl.Subject.CommonName = "ca"
+			parent = tmpl
+		}
+		der, err := x509.CreateCertificate(rand.Reader, tmpl, parent, key.Public(), caKey)
+		if err != nil {
+			t.Fatalf("Unexpected error occur: expect:%#v", err)
+		}
+		cert, err := x509.ParseCertificate(der)
+		if err != nil {
+			t.Fatalf("Unexpected error occur: expect:%#v", err)
+		}
+		return cert
+	}
+	ca := create(1, caKey, nil)
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	otherKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	return create(2, key, ca), create(3, otherKey, ca), ca
+}
+
+func tlsState(certs ...*x509.Certificate) *tls.ConnectionState {
+	return &tls.ConnectionState{PeerCertificates: certs}
+}
+
+// verifiedState returns the state whose chain is verified by the handshake.
+func verifiedState(certs ...*x509.Certificate) *tls.ConnectionState {
+	return &tls.ConnectionState{PeerCertificates: certs, VerifiedChains: [][]*x509.Certificate{certs}}
+}
+
+func TestExtractCertificate(t *testing.T) {
+	cert := getCertificate(t)
+	other := getCertificate(t)
+	escaped := url.PathEscape(string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})))
+	sum := sha256.Sum256(cert.Raw)
+	otherSum := sha256.Sum256(other.Raw)
+	tp, _ := mtls_token.CertificateThumbprint([]*x509.Certificate{cert})
+
+	xfcc, err := NewXFCCExtractor("10.0.0.0/8", "::1")
+	if err != nil {
+		t.Fatalf("Unexpected error occur: expect:%#v", err)
+	}
+	nginx, err := NewEscapedCertExtractor("X-SSL-Client-Cert", "192.168.0.1")
+	if err != nil {
+		t.Fatalf("Unexpected error occur: expect:%#v", err)
+	}
+
+	tcs := map[string]struct {
+		extractor  CertificateExtractor
+		remoteAddr string
+		header     http.Header
+		certs      int
+		err        bool
+	}{
+		"xfcc cert and hash": {
+			extractor:  xfcc,
+			remoteAddr: "10.1.2.3:4567",
+			header: http.Header{XFCCHeader: {
+				`By=spiffe://example.com/server;Hash=` + hex.EncodeToString(sum[:]) + `;Cert="` + escaped + `";Subject="CN=client,O=\"kokukuma\""`,
+			}},
+			certs: 1,
+		},
+		"xfcc hash only": {
+			extractor:  xfcc,
+			remoteAddr: "[::1]:4567",
+			header:     http.Header{XFCCHeader: {"Hash=" + hex.EncodeToString(sum[:])}},
+		},
+		"xfcc last element": {
+			extractor:  xfcc,
+			remoteAddr: "10.1.2.3:4567",
+			header: http.Header{XFCCHeader: {
+				"Hash=" + hex.EncodeToString(otherSum[:]) + ",Hash=" + hex.EncodeToString(sum[:]),
+			}},
+		},
+		"xfcc hash mismatch": {
+			extractor:  xfcc,
+			remoteAddr: "10.1.2.3:4567",
+			header: http.Header{XFCCHeader: {
+				"Hash=" + hex.EncodeToString(otherSum[:]) + `;Cert="` + escaped + `"`,
+			}},
+			err: true,
+		},
+		"xfcc untrusted proxy": {
+			extractor:  xfcc,
+			remoteAddr: "192.168.0.1:4567",
+			header:     http.Header{XFCCHeader: {"Hash=" + hex.EncodeToString(sum[:])}},
+			err:        true,
+		},
+		"xfcc no header": {
+			extractor:  xfcc,
+			remoteAddr: "10.1.2.3:4567",
+			header:     http.Header{},
+			err:        true,
+		},
+		"nginx escaped cert": {
+			extractor:  nginx,
+			remoteAddr: "192.168.0.1:4567",
+			header:     http.Header{"X-Ssl-Client-Cert": {escaped}},
+			certs:      1,
+		},
+		"nginx untrusted proxy": {
+			extractor:  nginx,
+			remoteAddr: "192.168.0.2:4567",
+			header:     http.Header{"X-Ssl-Client-Cert": {escaped}},
+			err:        true,
+		},
+	}
+
+	for name, tc := range tcs {
+		req := &http.Request{RemoteAddr: tc.remoteAddr, Header: tc.header}
+		c, err := tc.extractor.ExtractCertificate(req)
+		if tc.err {
+			if err == nil {
+				t.Errorf("Should be error occur in %s", name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("Unexpected error occur in %s: expect:%#v", name, err)
+		}
+		if c.Thumbprint != tp || len(c.Certificates) != tc.certs || !c.Verified {
+			t.Errorf("Unexpected certificate: %s: expect:%#v, given:%#v", name, tp, c)
+		}
+	}
+}
+
+func TestTLSExtractor(t *testing.T) {
+	cert := getCertificate(t)
+
+	tcs := map[string]struct {
+		state    *tls.ConnectionState
+		verified bool
+	}{
+		"verified chain": {
+			state:    verifiedState(cert),
+			verified: true,
+		},
+		"peer certificates only": {
+			state: tlsState(cert),
+		},
+	}
+
+	for name, tc := range tcs {
+		c, err := TLSExtractor.ExtractCertificate(&http.Request{TLS: tc.state})
+		if err != nil {
+			t.Fatalf("Unexpected error occur in %s: expect:%#v", name, err)
+		}
+		if c.Verified != tc.verified || len(c.Certificates) != 1 {
+			t.Errorf("Unexpected certificate: %s: %#v", name, c)
+		}
+	}
+}
+
+func TestNewProxyExtractorFailed(t *testing.T) {
+	tcs := map[string][]string{
+		"no proxy":    nil,
+		"invalid ip":  {"10.0.0.300"},
+		"invalid net": {"10.0.0.0/33"},
+	}
+
+	for name, proxies := range tcs {
+		if _, err := NewXFCCExtractor(proxies...); err == nil {
+			t.Errorf("Should be error occur in %s", name)
+		}
+	}
+}
+
+func TestDecodeTokenWithExtractor(t *testing.T) {
+	cert := getCertificate(t)
+	sum := sha256.Sum256(cert.Raw)
+
+	token, err := mtls_token.IssueToken(tlsState(cert), []byte("secret"), mtls_token.RawClaims{})
+	if err != nil {
+		t.Fatalf("Unexpected error occur: expect:%#v", err)
+	}
+	verifier, err := mtls_token.NewVerifier([]byte("secret"))
+	if err != nil {
+		t.Fatalf("Unexpected error occur: expect:%#v", err)
+	}
+	xfcc, err := NewXFCCExtractor("127.0.0.1")
+	if err != nil {
+		t.Fatalf("Unexpected error occur: expect:%#v", err)
+	}
+
+	req := &http.Request{
+		RemoteAddr: "127.0.0.1:4567",
+		Header:     http.Header{XFCCHeader: {"Hash=" + hex.EncodeToString(sum[:])}},
+	}
+	if _, err := DecodeTokenWithExtractor(req, token, verifier, xfcc); err != nil {
+		t.Errorf("Unexpected error occur: %#v", err)
+	}
+
+	other := getCertificate(t)
+	otherSum := sha256.Sum256(other.Raw)
+	req.Header.Set(XFCCHeader, "Hash="+hex.EncodeToString(otherSum[:]))
+	if _, err := DecodeTokenWithExtractor(req, token, verifier, xfcc); err == nil {
+		t.Errorf("Should be error occur")
+	}
+}
+
+func TestDecodeTokenWithExtractorIntermediate(t *testing.T) {
+	leaf, other, ca := getCAChain(t)
+	secret := []byte("secret")
+
+	token, err := mtls_token.IssueToken(verifiedState(leaf, ca), secret, mtls_token.RawClaims{},
+		mtls_token.WithConfirmation(mtls_token.IntermediateConfirmation))
+	if err != nil {
+		t.Fatalf("Unexpected error occur: expect:%#v", err)
+	}
+	verifier, err := mtls_token.NewVerifier(secret, mtls_token.WithAcceptedConfirmations(mtls_token.IntermediateConfirmation))
+	if err != nil {
+		t.Fatalf("Unexpected error occur: expect:%#v", err)
+	}
+
+	tcs := map[string]struct {
+		state *tls.ConnectionState
+		err   error
+	}{
+		"verified chain": {
+			state: verifiedState(other, ca),
+		},
+		"not verified chain": {
+			state: tlsState(other, ca),
+			err:   mtls_token.ErrVerifyPoP,
+		},
+	}
+
+	for name, tc := range tcs {
+		_, err := DecodeTokenWithExtractor(&http.Request{TLS: tc.state}, token, verifier, TLSExtractor)
+		if tc.err == nil && err != nil {
+			t.Errorf("Unexpected error occur: %s: %#v", name, err)
+		}
+		if tc.err != nil && !errors.Is(err, tc.err) {
+			t.Errorf("Unexpeccted error occur: %s: expect:%#v, given:%#v", name, tc.err, err)
+		}
+	}
+}
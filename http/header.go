@@ -0,0 +1,31 @@
+package http
+
+import (
+	"net/http"
+
+	mtls_token "github.com/kokukuma/mtls-token"
+)
+
+const authorizationHeader = "Authorization"
+
+// GetTokenFromRequest returns token on Authorization header.
+// The scheme must be Bearer.
+// https://tools.ietf.org/html/rfc6750#section-2.1
+func GetTokenFromRequest(req *http.Request) (string, error) {
+	cred, err := GetCredentialFromRequest(req, mtls_token.DefaultCredentialExtractor)
+	if err != nil {
+		return "", err
+	}
+	return cred.Token, nil
+}
+
+// GetCredentialFromRequest returns the credential on Authorization header
+// parsed by extractor.
+func GetCredentialFromRequest(req *http.Request, extractor *mtls_token.CredentialExtractor) (*mtls_token.Credential, error) {
+	return extractor.Extract(req.Header[authorizationHeader])
+}
+
+// AddTokenToRequest sets token on Authorization header.
+func AddTokenToRequest(req *http.Request, token string) {
+	req.Header.Set(authorizationHeader, "Bearer "+token)
+}
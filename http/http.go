@@ -1,7 +1,6 @@
 package http
 
 import (
-	"crypto/rsa"
 	"errors"
 	"net/http"
 
@@ -9,19 +8,63 @@ import (
 )
 
 // IssueToken creates access token.
-func IssueToken(req *http.Request, privateKey *rsa.PrivateKey, claims mtls_token.RawClaims) (string, error) {
+func IssueToken(req *http.Request, privateKey interface{}, claims mtls_token.RawClaims, opts ...mtls_token.IssuerOption) (string, error) {
 	if req == nil {
 		return "", errors.New("http request is nil")
 	}
 	state := req.TLS
-	return mtls_token.IssueToken(state, privateKey, claims)
+	return mtls_token.IssueToken(state, privateKey, claims, opts...)
 }
 
 // DecodeToken decode token
-func DecodeToken(resp *http.Response, payload string, publicKey *rsa.PublicKey) (*mtls_token.JWT, error) {
+// It is used by the client which received the token on the response.
+// Use DecodeRequest in resource server.
+func DecodeToken(resp *http.Response, payload string, verifier *mtls_token.Verifier) (*mtls_token.JWT, error) {
 	if resp == nil {
 		return nil, errors.New("http response is nil")
 	}
+	if verifier == nil {
+		return nil, errors.New("verifier is nil")
+	}
 	state := resp.TLS
-	return mtls_token.DecodeToken(state, payload, publicKey)
+	return verifier.Verify(state, payload)
+}
+
+// DecodeRequest verifies the token on Authorization header of the request.
+// The token must be bound to the client certificate of the request.
+func DecodeRequest(req *http.Request, verifier *mtls_token.Verifier) (*mtls_token.JWT, error) {
+	if req == nil {
+		return nil, errors.New("http request is nil")
+	}
+	if verifier == nil {
+		return nil, errors.New("verifier is nil")
+	}
+	payload, err := GetTokenFromRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	return verifier.Verify(req.TLS, payload)
+}
+
+// DecodeTokenWithExtractor decodes token bound to the client certificate
+// returned by extractor. It is used when TLS is terminated by a proxy.
+func DecodeTokenWithExtractor(req *http.Request, payload string, verifier *mtls_token.Verifier, extractor CertificateExtractor) (*mtls_token.JWT, error) {
+	if req == nil {
+		return nil, errors.New("http request is nil")
+	}
+	if verifier == nil {
+		return nil, errors.New("verifier is nil")
+	}
+	if extractor == nil {
+		extractor = TLSExtractor
+	}
+
+	cert, err := extractor.ExtractCertificate(req)
+	if err != nil {
+		return nil, err
+	}
+	if len(cert.Certificates) > 0 {
+		return verifier.VerifyCertificates(cert.Certificates, cert.Verified, payload)
+	}
+	return verifier.VerifyThumbprint(cert.Thumbprint, payload)
 }
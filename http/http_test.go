@@ -0,0 +1,112 @@
+package http
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	mtls_token "github.com/kokukuma/mtls-token"
+)
+
+func TestGetTokenFromRequest(t *testing.T) {
+	tcs := map[string]struct {
+		values []string
+		token  string
+	}{
+		"bearer":           {values: []string{"Bearer token"}, token: "token"},
+		"case-insensitive": {values: []string{"bearer token"}, token: "token"},
+		"no header":        {},
+		"basic":            {values: []string{"Basic dXNlcjpwYXNz"}},
+		"no token":         {values: []string{"Bearer "}},
+		"multiple":         {values: []string{"Bearer token", "Bearer other"}},
+	}
+
+	for name, tc := range tcs {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		for _, v := range tc.values {
+			req.Header.Add("Authorization", v)
+		}
+		token, err := GetTokenFromRequest(req)
+		if tc.token == "" {
+			if err == nil {
+				t.Errorf("Should be error occur in %s", name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("Unexpected error occur in %s: expect:%#v", name, err)
+		}
+		if token != tc.token {
+			t.Errorf("Unexpected token: %s: expect:%#v, given:%#v", name, tc.token, token)
+		}
+	}
+}
+
+func TestDecodeRequest(t *testing.T) {
+	ecdsaKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	_, ed25519Key, _ := ed25519.GenerateKey(rand.Reader)
+
+	cert := getCertificate(t)
+	other := getCertificate(t)
+
+	tcs := map[string]struct {
+		privateKey interface{}
+		publicKey  interface{}
+		certs      []*x509.Certificate
+		err        error
+	}{
+		"ecdsa": {
+			privateKey: ecdsaKey,
+			publicKey:  &ecdsaKey.PublicKey,
+			certs:      []*x509.Certificate{cert},
+		},
+		"ed25519": {
+			privateKey: ed25519Key,
+			publicKey:  ed25519Key.Public(),
+			certs:      []*x509.Certificate{cert},
+		},
+		"other certificate": {
+			privateKey: ecdsaKey,
+			publicKey:  &ecdsaKey.PublicKey,
+			certs:      []*x509.Certificate{other},
+			err:        mtls_token.ErrVerifyPoP,
+		},
+		"no tls": {
+			privateKey: ecdsaKey,
+			publicKey:  &ecdsaKey.PublicKey,
+			err:        mtls_token.ErrMutualTLSConnection,
+		},
+	}
+
+	for name, tc := range tcs {
+		token, err := mtls_token.IssueToken(tlsState(cert), tc.privateKey, mtls_token.RawClaims{})
+		if err != nil {
+			t.Fatalf("Unexpected error occur in %s: expect:%#v", name, err)
+		}
+		verifier, err := mtls_token.NewVerifier(tc.publicKey)
+		if err != nil {
+			t.Fatalf("Unexpected error occur in %s: expect:%#v", name, err)
+		}
+
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.TLS = nil
+		if tc.certs != nil {
+			req.TLS = tlsState(tc.certs...)
+		}
+		AddTokenToRequest(req, token)
+
+		_, err = DecodeRequest(req, verifier)
+		if tc.err == nil && err != nil {
+			t.Errorf("Unexpected error occur: %s: %#v", name, err)
+		}
+		if tc.err != nil && !errors.Is(err, tc.err) {
+			t.Errorf("Unexpeccted error occur: %s: expect:%#v, given:%#v", name, tc.err, err)
+		}
+	}
+}
@@ -0,0 +1,203 @@
+package http
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+
+	mtls_token "github.com/kokukuma/mtls-token"
+)
+
+const maxIntrospectionSize = 1 << 20
+
+// IntrospectionHandler is the introspection endpoint.
+// The token is verified except for the proof of possession, and the claims
+// are responded with cnf, so that the resource server can check the proof
+// of possession by the client certificate of its own TLS connection.
+// The caller is authenticated by the client certificate registered in ClientStore.
+// tls_client_auth callers need the chain verified by the TLS handshake or the proxy.
+// https://tools.ietf.org/html/rfc7662#section-2
+// https://tools.ietf.org/html/rfc8705#section-3.2
+type IntrospectionHandler struct {
+	verifier  *mtls_token.Verifier
+	store     mtls_token.ClientStore
+	extractor CertificateExtractor
+}
+
+// IntrospectionHandlerOption configures IntrospectionHandler.
+type IntrospectionHandlerOption func(*IntrospectionHandler)
+
+// WithIntrospectionCertificateExtractor sets how the certificate of the caller is gotten.
+// The extractor must return the certificate, not only the thumbprint.
+// If it is not set, the certificate of the TLS connection is used.
+func WithIntrospectionCertificateExtractor(extractor CertificateExtractor) IntrospectionHandlerOption {
+	return func(h *IntrospectionHandler) {
+		h.extractor = extractor
+	}
+}
+
+// NewIntrospectionHandler creates IntrospectionHandler.
+// store has the registrations of the resource servers which call it.
+func NewIntrospectionHandler(verifier *mtls_token.Verifier, store mtls_token.ClientStore, opts ...IntrospectionHandlerOption) (*IntrospectionHandler, error) {
+	if verifier == nil {
+		return nil, errors.New("verifier is nil")
+	}
+	if store == nil {
+		return nil, errors.New("client store is nil")
+	}
+	h := &IntrospectionHandler{
+		verifier:  verifier,
+		store:     store,
+		extractor: TLSExtractor,
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h, nil
+}
+
+// ServeHTTP responds whether the token is active.
+// The token which fails verification is responded as inactive without the reason.
+// https://tools.ietf.org/html/rfc7662#section-2.2
+func (h *IntrospectionHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		writeError(w, http.StatusMethodNotAllowed, errInvalidRequest, "introspection request must be POST")
+		return
+	}
+	if mt, _, err := mime.ParseMediaType(r.Header.Get("Content-Type")); err != nil || mt != "application/x-www-form-urlencoded" {
+		writeError(w, http.StatusBadRequest, errInvalidRequest, "content type must be application/x-www-form-urlencoded")
+		return
+	}
+
+	cert, err := h.extractor.ExtractCertificate(r)
+	if err != nil || len(cert.Certificates) == 0 {
+		writeError(w, http.StatusUnauthorized, errInvalidClient, "client certificate is required")
+		return
+	}
+	if _, err := h.store.LookupClientByCertificate(cert.Certificates, cert.Verified); err != nil {
+		writeError(w, http.StatusUnauthorized, errInvalidClient, "")
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		writeError(w, http.StatusBadRequest, errInvalidRequest, "failed to parse the request")
+		return
+	}
+	if len(r.PostForm["token"]) != 1 || r.PostForm.Get("token") == "" {
+		writeError(w, http.StatusBadRequest, errInvalidRequest, "token is required")
+		return
+	}
+
+	jwt, err := h.verifier.VerifyWithoutPoP(r.PostForm.Get("token"))
+	if err != nil {
+		writeJSON(w, http.StatusOK, map[string]interface{}{"active": false})
+		return
+	}
+
+	resp := map[string]interface{}{}
+	for k, v := range jwt.Claims() {
+		resp[k] = v
+	}
+	resp["active"] = true
+	resp["token_type"] = "Bearer"
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// IntrospectionClient is used by the resource server which cannot verify
+// the token by itself. The client certificate of the request is compared
+// with cnf of the introspection response.
+type IntrospectionClient struct {
+	endpoint string
+	client   *http.Client
+	cnfs     []mtls_token.Confirmation
+}
+
+// IntrospectionClientOption configures IntrospectionClient.
+type IntrospectionClientOption func(*IntrospectionClient)
+
+// WithIntrospectionConfirmations sets the accepted members of cnf.
+// The default is CertificateConfirmation.
+func WithIntrospectionConfirmations(cnfs ...mtls_token.Confirmation) IntrospectionClientOption {
+	return func(c *IntrospectionClient) {
+		c.cnfs = append(c.cnfs, cnfs...)
+	}
+}
+
+// NewIntrospectionClient creates IntrospectionClient.
+// client should be configured with the certificate of the resource server,
+// which is authenticated by the introspection endpoint.
+func NewIntrospectionClient(endpoint string, client *http.Client, opts ...IntrospectionClientOption) (*IntrospectionClient, error) {
+	if u, err := url.Parse(endpoint); err != nil || !u.IsAbs() {
+		return nil, fmt.Errorf("invalid introspection endpoint: %q", endpoint)
+	}
+	if client == nil {
+		client = http.DefaultClient
+	}
+	c := &IntrospectionClient{endpoint: endpoint, client: client}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c, nil
+}
+
+// Introspect returns the claims of the active token.
+// ErrTokenInactive is returned if the token is not active.
+// The proof of possession is not checked.
+func (c *IntrospectionClient) Introspect(ctx context.Context, token string) (mtls_token.RawClaims, error) {
+	body := url.Values{"token": {token}, "token_type_hint": {"access_token"}}.Encode()
+	req, err := http.NewRequest(http.MethodPost, c.endpoint, strings.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to introspect: %s", resp.Status)
+	}
+	b, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxIntrospectionSize))
+	if err != nil {
+		return nil, err
+	}
+
+	claims := mtls_token.RawClaims{}
+	if err := json.Unmarshal(b, &claims); err != nil {
+		return nil, err
+	}
+	if active, _ := claims["active"].(bool); !active {
+		return nil, mtls_token.ErrTokenInactive
+	}
+	return claims, nil
+}
+
+// Verify introspects the token and checks that it is bound to the client
+// certificate of state in the same way as DecodeToken.
+func (c *IntrospectionClient) Verify(ctx context.Context, state *tls.ConnectionState, token string) (mtls_token.RawClaims, error) {
+	if state == nil {
+		return nil, mtls_token.ErrMutualTLSConnection
+	}
+	claims, err := c.Introspect(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	if err := mtls_token.VerifyPoP(state, claims, c.cnfs...); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
@@ -0,0 +1,242 @@
+package http
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	mtls_token "github.com/kokukuma/mtls-token"
+)
+
+func TestIntrospection(t *testing.T) {
+	cert := getCertificate(t)
+	other := getCertificate(t)
+	rs := getCertificate(t)
+	secret := []byte("secret")
+
+	token, err := mtls_token.IssueToken(tlsState(cert), secret, mtls_token.RawClaims{"sub": "client", "scope": "read"})
+	if err != nil {
+		t.Fatalf("Unexpected error occur: expect:%#v", err)
+	}
+	verifier, err := mtls_token.NewVerifier(secret)
+	if err != nil {
+		t.Fatalf("Unexpected error occur: expect:%#v", err)
+	}
+	jwk, err := mtls_token.NewJWK(rs.PublicKey)
+	if err != nil {
+		t.Fatalf("Unexpected error occur: expect:%#v", err)
+	}
+	store, err := mtls_token.NewMemoryClientStore(&mtls_token.Client{
+		ID:         "resource-server",
+		AuthMethod: mtls_token.AuthMethodSelfSignedTLSClientAuth,
+		JWKS:       mtls_token.NewKeySet(jwk),
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error occur: expect:%#v", err)
+	}
+	handler, err := NewIntrospectionHandler(verifier, store)
+	if err != nil {
+		t.Fatalf("Unexpected error occur: expect:%#v", err)
+	}
+
+	tcs := map[string]struct {
+		body   string
+		caller bool
+		status int
+		active bool
+	}{
+		"active": {
+			body:   url.Values{"token": {token}}.Encode(),
+			caller: true,
+			status: http.StatusOK,
+			active: true,
+		},
+		"inactive": {
+			body:   url.Values{"token": {"invalid"}}.Encode(),
+			caller: true,
+			status: http.StatusOK,
+		},
+		"no token": {
+			body:   url.Values{"token_type_hint": {"access_token"}}.Encode(),
+			caller: true,
+			status: http.StatusBadRequest,
+		},
+		"unauthenticated caller": {
+			body:   url.Values{"token": {token}}.Encode(),
+			status: http.StatusUnauthorized,
+		},
+	}
+
+	for name, tc := range tcs {
+		req := httptest.NewRequest(http.MethodPost, "/introspect", strings.NewReader(tc.body))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		if tc.caller {
+			req.TLS = tlsState(rs)
+		} else {
+			req.TLS = tlsState(other)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		if rec.Code != tc.status {
+			t.Errorf("Unexpected status: %s: expect:%d, given:%d", name, tc.status, rec.Code)
+			continue
+		}
+		if tc.status != http.StatusOK {
+			continue
+		}
+		var resp mtls_token.RawClaims
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Errorf("Unexpeccted error occur: %s: %#v", name, err)
+			continue
+		}
+		if resp["active"] != tc.active {
+			t.Errorf("Unexpected active: %s: expect:%v, given:%v", name, tc.active, resp["active"])
+		}
+		if tc.active && resp.GetX5tS256() == "" {
+			t.Errorf("cnf must be in the response: %s: %v", name, resp)
+		}
+		if !tc.active && len(resp) != 1 {
+			t.Errorf("Inactive response must have only active: %s: %v", name, resp)
+		}
+	}
+
+}
+
+func TestIntrospectionCallerNotVerified(t *testing.T) {
+	rs := getCertificate(t)
+	verifier, err := mtls_token.NewVerifier([]byte("secret"))
+	if err != nil {
+		t.Fatalf("Unexpected error occur: expect:%#v", err)
+	}
+	store, err := mtls_token.NewMemoryClientStore(&mtls_token.Client{
+		ID:         "resource-server",
+		AuthMethod: mtls_token.AuthMethodTLSClientAuth,
+		SubjectDN:  "CN=client",
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error occur: expect:%#v", err)
+	}
+	handler, err := NewIntrospectionHandler(verifier, store)
+	if err != nil {
+		t.Fatalf("Unexpected error occur: expect:%#v", err)
+	}
+
+	tcs := map[string]struct {
+		state  *tls.ConnectionState
+		status int
+	}{
+		"verified": {
+			state:  verifiedState(rs),
+			status: http.StatusOK,
+		},
+		"not verified": {
+			state:  tlsState(rs),
+			status: http.StatusUnauthorized,
+		},
+	}
+
+	for name, tc := range tcs {
+		body := url.Values{"token": {"invalid"}}.Encode()
+		req := httptest.NewRequest(http.MethodPost, "/introspect", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.TLS = tc.state
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		if rec.Code != tc.status {
+			t.Errorf("Unexpected status: %s: expect:%d, given:%d", name, tc.status, rec.Code)
+		}
+	}
+}
+
+func TestIntrospectionClient(t *testing.T) {
+	cert := getCertificate(t)
+	other := getCertificate(t)
+	rs := getCertificate(t)
+	secret := []byte("secret")
+
+	token, err := mtls_token.IssueToken(tlsState(cert), secret, mtls_token.RawClaims{"sub": "client"})
+	if err != nil {
+		t.Fatalf("Unexpected error occur: expect:%#v", err)
+	}
+	verifier, err := mtls_token.NewVerifier(secret)
+	if err != nil {
+		t.Fatalf("Unexpected error occur: expect:%#v", err)
+	}
+	jwk, err := mtls_token.NewJWK(rs.PublicKey)
+	if err != nil {
+		t.Fatalf("Unexpected error occur: expect:%#v", err)
+	}
+	store, err := mtls_token.NewMemoryClientStore(&mtls_token.Client{
+		ID:         "resource-server",
+		AuthMethod: mtls_token.AuthMethodSelfSignedTLSClientAuth,
+		JWKS:       mtls_token.NewKeySet(jwk),
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error occur: expect:%#v", err)
+	}
+
+	// The test server is not mutual TLS, so the certificate of the caller is given by the extractor.
+	handler, err := NewIntrospectionHandler(verifier, store, WithIntrospectionCertificateExtractor(
+		CertificateExtractorFunc(func(*http.Request) (*ClientCertificate, error) {
+			return &ClientCertificate{Certificates: []*x509.Certificate{rs}}, nil
+		}),
+	))
+	if err != nil {
+		t.Fatalf("Unexpected error occur: expect:%#v", err)
+	}
+	srv := httptest.NewServer(handler)
+	defer srv.Close()
+
+	client, err := NewIntrospectionClient(srv.URL, srv.Client())
+	if err != nil {
+		t.Fatalf("Unexpected error occur: expect:%#v", err)
+	}
+
+	tcs := map[string]struct {
+		state *tls.ConnectionState
+		token string
+		err   error
+	}{
+		"bound": {
+			state: tlsState(cert),
+			token: token,
+		},
+		"other certificate": {
+			state: tlsState(other),
+			token: token,
+			err:   mtls_token.ErrVerifyPoP,
+		},
+		"no certificate": {
+			state: tlsState(),
+			token: token,
+			err:   mtls_token.ErrMutualTLSConnection,
+		},
+		"inactive": {
+			state: tlsState(cert),
+			token: "invalid",
+			err:   mtls_token.ErrTokenInactive,
+		},
+	}
+
+	for name, tc := range tcs {
+		claims, err := client.Verify(context.Background(), tc.state, tc.token)
+		if !errors.Is(err, tc.err) {
+			t.Errorf("Unexpeccted error occur: %s: expect:%#v, given:%#v", name, tc.err, err)
+			continue
+		}
+		if tc.err == nil {
+			if sub, _ := claims.GetString("sub"); sub != "client" {
+				t.Errorf("Unexpected sub: %s: %v", name, claims)
+			}
+		}
+	}
+}
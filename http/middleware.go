@@ -0,0 +1,209 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+
+	mtls_token "github.com/kokukuma/mtls-token"
+)
+
+type contextKey struct{}
+
+type credentialContextKey struct{}
+
+// JWTFromContext returns the token verified by Middleware.
+func JWTFromContext(ctx context.Context) (*mtls_token.JWT, bool) {
+	jwt, ok := ctx.Value(contextKey{}).(*mtls_token.JWT)
+	return jwt, ok
+}
+
+// ContextWithJWT returns context that has the token.
+func ContextWithJWT(ctx context.Context, jwt *mtls_token.JWT) context.Context {
+	return context.WithValue(ctx, contextKey{}, jwt)
+}
+
+// CredentialFromContext returns the credential of the token verified by Middleware.
+// The handler which accepts DPoP validates the proof when Scheme is DPoP.
+func CredentialFromContext(ctx context.Context) (*mtls_token.Credential, bool) {
+	cred, ok := ctx.Value(credentialContextKey{}).(*mtls_token.Credential)
+	return cred, ok
+}
+
+// ContextWithCredential returns context that has the credential.
+func ContextWithCredential(ctx context.Context, cred *mtls_token.Credential) context.Context {
+	return context.WithValue(ctx, credentialContextKey{}, cred)
+}
+
+type middleware struct {
+	verifier    *mtls_token.Verifier
+	extractor   CertificateExtractor
+	credentials *mtls_token.CredentialExtractor
+	realm       string
+	skipPaths   []string
+	scopes      []string
+}
+
+// MiddlewareOption configures Middleware.
+type MiddlewareOption func(*middleware)
+
+// WithSkipPaths sets the paths which are served without the token.
+// The path which ends with "/" matches itself and every path under it.
+// The request path is cleaned before it is matched, so "/public/../admin"
+// does not match "/public/".
+func WithSkipPaths(paths ...string) MiddlewareOption {
+	return func(m *middleware) {
+		m.skipPaths = append(m.skipPaths, paths...)
+	}
+}
+
+// WithRequiredScopes sets the scopes which the token must have.
+func WithRequiredScopes(scopes ...string) MiddlewareOption {
+	return func(m *middleware) {
+		m.scopes = append(m.scopes, scopes...)
+	}
+}
+
+// WithRealm sets realm of WWW-Authenticate.
+func WithRealm(realm string) MiddlewareOption {
+	return func(m *middleware) {
+		m.realm = realm
+	}
+}
+
+// WithCertificateExtractor sets how the client certificate is gotten.
+// If it is not set, the certificate of the TLS connection is used.
+func WithCertificateExtractor(extractor CertificateExtractor) MiddlewareOption {
+	return func(m *middleware) {
+		m.extractor = extractor
+	}
+}
+
+// WithCredentialExtractor sets the accepted schemes of Authorization header.
+// The default is Bearer. The DPoP proof is not validated by Middleware.
+func WithCredentialExtractor(extractor *mtls_token.CredentialExtractor) MiddlewareOption {
+	return func(m *middleware) {
+		m.credentials = extractor
+	}
+}
+
+// Middleware verifies the token on Authorization header before the handler is called.
+// The verified token can be gotten by JWTFromContext, and its scheme by CredentialFromContext.
+// On failure, the error is responded with WWW-Authenticate of the scheme of the request,
+// or of every accepted scheme if the scheme is not known.
+// It panics if verifier is nil.
+// https://tools.ietf.org/html/rfc6750#section-3
+func Middleware(verifier *mtls_token.Verifier, opts ...MiddlewareOption) func(http.Handler) http.Handler {
+	if verifier == nil {
+		panic("verifier is nil")
+	}
+	m := &middleware{
+		verifier:    verifier,
+		credentials: mtls_token.DefaultCredentialExtractor,
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if m.skip(r.URL.Path) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			cred, err := GetCredentialFromRequest(r, m.credentials)
+			if errors.Is(err, mtls_token.ErrNoCredential) {
+				m.challenge(w, m.credentials.Schemes(), http.StatusUnauthorized, "", "")
+				return
+			}
+			if err != nil {
+				m.challenge(w, m.credentials.Schemes(), http.StatusBadRequest, "invalid_request", err.Error())
+				return
+			}
+			schemes := []string{cred.Scheme}
+
+			jwt, err := m.verify(r, cred.Token)
+			if err != nil {
+				m.challenge(w, schemes, http.StatusUnauthorized, "invalid_token", err.Error())
+				return
+			}
+			if !jwt.Claims().HasScopes(m.scopes...) {
+				m.challenge(w, schemes, http.StatusForbidden, "insufficient_scope", "")
+				return
+			}
+
+			ctx := ContextWithCredential(ContextWithJWT(r.Context(), jwt), cred)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+func (m *middleware) skip(p string) bool {
+	p = cleanPath(p)
+	for _, s := range m.skipPaths {
+		if cleanPath(s) == p {
+			return true
+		}
+		if strings.HasSuffix(s, "/") && strings.HasPrefix(p, cleanPath(s)+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// cleanPath returns the canonical path, e.g. "/a/../b/" is "/b".
+func cleanPath(p string) string {
+	if p == "" || p[0] != '/' {
+		p = "/" + p
+	}
+	return path.Clean(p)
+}
+
+func (m *middleware) verify(r *http.Request, payload string) (*mtls_token.JWT, error) {
+	if m.extractor != nil {
+		return DecodeTokenWithExtractor(r, payload, m.verifier, m.extractor)
+	}
+	return m.verifier.Verify(r.TLS, payload)
+}
+
+// challenge writes the error with a challenge per scheme.
+// https://tools.ietf.org/html/rfc6750#section-3.1
+func (m *middleware) challenge(w http.ResponseWriter, schemes []string, status int, code, description string) {
+	var params []string
+	if m.realm != "" {
+		params = append(params, fmt.Sprintf("realm=%q", quoteSafe(m.realm)))
+	}
+	if code != "" {
+		params = append(params, fmt.Sprintf("error=%q", code))
+	}
+	if description != "" {
+		params = append(params, fmt.Sprintf("error_description=%q", quoteSafe(description)))
+	}
+	if code == "insufficient_scope" {
+		params = append(params, fmt.Sprintf("scope=%q", quoteSafe(strings.Join(m.scopes, " "))))
+	}
+
+	for _, scheme := range schemes {
+		challenge := scheme
+		if len(params) > 0 {
+			challenge += " " + strings.Join(params, ", ")
+		}
+		w.Header().Add("WWW-Authenticate", challenge)
+	}
+	http.Error(w, http.StatusText(status), status)
+}
+
+// quoteSafe removes the characters which cannot be in the quoted string.
+// https://tools.ietf.org/html/rfc6750#section-3
+func quoteSafe(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r < 0x20 || r > 0x7e || r == '"' || r == '\\' {
+			return -1
+		}
+		return r
+	}, s)
+}
@@ -0,0 +1,192 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	mtls_token "github.com/kokukuma/mtls-token"
+)
+
+func TestMiddleware(t *testing.T) {
+	cert := getCertificate(t)
+	secret := []byte("secret")
+	issue := func(rc mtls_token.RawClaims) string {
+		token, err := mtls_token.IssueToken(tlsState(cert), secret, rc)
+		if err != nil {
+			t.Fatalf("Unexpected error occur: expect:%#v", err)
+		}
+		return token
+	}
+	verifier, err := mtls_token.NewVerifier(secret)
+	if err != nil {
+		t.Fatalf("Unexpected error occur: expect:%#v", err)
+	}
+
+	handler := Middleware(verifier,
+		WithRealm("example"),
+		WithSkipPaths("/healthz", "/public/"),
+		WithRequiredScopes("read"),
+	)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := JWTFromContext(r.Context()); !ok && r.URL.Path == "/resource" {
+			t.Errorf("Token must be in context")
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	tcs := map[string]struct {
+		path          string
+		authorization []string
+		status        int
+		challenge     string
+	}{
+		"valid": {
+			path:          "/resource",
+			authorization: []string{"Bearer " + issue(mtls_token.RawClaims{"scope": "read write"})},
+			status:        http.StatusOK,
+		},
+		"skip path": {
+			path:   "/healthz",
+			status: http.StatusOK,
+		},
+		"skip prefix": {
+			path:   "/public/index.html",
+			status: http.StatusOK,
+		},
+		"skip prefix itself": {
+			path:   "/public",
+			status: http.StatusOK,
+		},
+		"skip path not cleaned": {
+			path:   "/public/../healthz",
+			status: http.StatusOK,
+		},
+		"not skip other segment": {
+			path:      "/publicity",
+			status:    http.StatusUnauthorized,
+			challenge: `Bearer realm="example"`,
+		},
+		"not skip dot dot": {
+			path:      "/public/../admin",
+			status:    http.StatusUnauthorized,
+			challenge: `Bearer realm="example"`,
+		},
+		"not skip under exact path": {
+			path:      "/healthz/admin",
+			status:    http.StatusUnauthorized,
+			challenge: `Bearer realm="example"`,
+		},
+		"no token": {
+			path:      "/resource",
+			status:    http.StatusUnauthorized,
+			challenge: `Bearer realm="example"`,
+		},
+		"invalid request": {
+			path:          "/resource",
+			authorization: []string{"Bearer a", "Bearer b"},
+			status:        http.StatusBadRequest,
+			challenge:     `Bearer realm="example", error="invalid_request", error_description="invalid client auth format: multiple client auth tokens"`,
+		},
+		"invalid token": {
+			path:          "/resource",
+			authorization: []string{"Bearer invalid"},
+			status:        http.StatusUnauthorized,
+			challenge:     `Bearer realm="example", error="invalid_token", error_description="invalid jwt format"`,
+		},
+		"insufficient scope": {
+			path:          "/resource",
+			authorization: []string{"Bearer " + issue(mtls_token.RawClaims{"scope": "write"})},
+			status:        http.StatusForbidden,
+			challenge:     `Bearer realm="example", error="insufficient_scope", scope="read"`,
+		},
+	}
+
+	for name, tc := range tcs {
+		req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+		req.TLS = tlsState(cert)
+		for _, v := range tc.authorization {
+			req.Header.Add("Authorization", v)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		if rec.Code != tc.status {
+			t.Errorf("Unexpected status: %s: expect:%#v, given:%#v", name, tc.status, rec.Code)
+		}
+		if given := rec.Header().Get("WWW-Authenticate"); given != tc.challenge {
+			t.Errorf("Unexpected challenge: %s: expect:%#v, given:%#v", name, tc.challenge, given)
+		}
+	}
+}
+
+func TestMiddlewareNilVerifier(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Should be panic with nil verifier")
+		}
+	}()
+	Middleware(nil)
+}
+
+func TestMiddlewareSchemes(t *testing.T) {
+	cert := getCertificate(t)
+	secret := []byte("secret")
+	token, err := mtls_token.IssueToken(tlsState(cert), secret, mtls_token.RawClaims{})
+	if err != nil {
+		t.Fatalf("Unexpected error occur: expect:%#v", err)
+	}
+	verifier, err := mtls_token.NewVerifier(secret)
+	if err != nil {
+		t.Fatalf("Unexpected error occur: expect:%#v", err)
+	}
+
+	var scheme string
+	handler := Middleware(verifier,
+		WithCredentialExtractor(mtls_token.NewCredentialExtractor(mtls_token.SchemeBearer, mtls_token.SchemeDPoP)),
+	)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if cred, ok := CredentialFromContext(r.Context()); ok {
+			scheme = cred.Scheme
+		}
+	}))
+
+	tcs := map[string]struct {
+		authorization string
+		status        int
+		scheme        string
+		challenges    []string
+	}{
+		"dpop": {
+			authorization: "dpop " + token,
+			status:        http.StatusOK,
+			scheme:        mtls_token.SchemeDPoP,
+		},
+		"dpop invalid token": {
+			authorization: "DPoP invalid",
+			status:        http.StatusUnauthorized,
+			challenges:    []string{`DPoP error="invalid_token", error_description="invalid jwt format"`},
+		},
+		"no token": {
+			status:     http.StatusUnauthorized,
+			challenges: []string{"Bearer", "DPoP"},
+		},
+	}
+
+	for name, tc := range tcs {
+		scheme = ""
+		req := httptest.NewRequest(http.MethodGet, "/resource", nil)
+		req.TLS = tlsState(cert)
+		if tc.authorization != "" {
+			req.Header.Set("Authorization", tc.authorization)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		if rec.Code != tc.status || scheme != tc.scheme {
+			t.Errorf("Unexpected response: %s: status:%d, scheme:%#v", name, rec.Code, scheme)
+		}
+		if given := rec.Header()["Www-Authenticate"]; !reflect.DeepEqual(given, tc.challenges) {
+			t.Errorf("Unexpected challenge: %s: expect:%#v, given:%#v", name, tc.challenges, given)
+		}
+	}
+}
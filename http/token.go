@@ -0,0 +1,210 @@
+package http
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+
+	mtls_token "github.com/kokukuma/mtls-token"
+)
+
+// OAuth 2.0 error codes of the token endpoint.
+// https://tools.ietf.org/html/rfc6749#section-5.2
+const (
+	errInvalidRequest       = "invalid_request"
+	errInvalidClient        = "invalid_client"
+	errInvalidScope         = "invalid_scope"
+	errUnsupportedGrantType = "unsupported_grant_type"
+	errServerError          = "server_error"
+)
+
+// TokenHandler is the token endpoint of client_credentials grant.
+// The client is authenticated by the client certificate, and the issued
+// token is bound to it. client_id, scope and the lifetime of the token are
+// taken from the registration in ClientStore.
+// https://tools.ietf.org/html/rfc6749#section-4.4
+// https://tools.ietf.org/html/rfc8705#section-2
+type TokenHandler struct {
+	issuer    *mtls_token.Issuer
+	store     mtls_token.ClientStore
+	extractor CertificateExtractor
+	iss       string
+	clock     func() time.Time
+}
+
+// TokenHandlerOption configures TokenHandler.
+type TokenHandlerOption func(*TokenHandler)
+
+// WithTokenIssuerName sets iss of the issued token.
+func WithTokenIssuerName(iss string) TokenHandlerOption {
+	return func(h *TokenHandler) {
+		h.iss = iss
+	}
+}
+
+// WithClientCertificateExtractor sets how the client certificate is gotten.
+// The extractor must return the certificate, not only the thumbprint.
+// If it is not set, the certificate of the TLS connection is used.
+func WithClientCertificateExtractor(extractor CertificateExtractor) TokenHandlerOption {
+	return func(h *TokenHandler) {
+		h.extractor = extractor
+	}
+}
+
+type tokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int64  `json:"expires_in,omitempty"`
+	Scope       string `json:"scope,omitempty"`
+}
+
+type errorResponse struct {
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description,omitempty"`
+}
+
+// NewTokenHandler creates TokenHandler.
+// store has the registrations of the clients.
+func NewTokenHandler(issuer *mtls_token.Issuer, store mtls_token.ClientStore, opts ...TokenHandlerOption) (*TokenHandler, error) {
+	if issuer == nil {
+		return nil, errors.New("issuer is nil")
+	}
+	if store == nil {
+		return nil, errors.New("client store is nil")
+	}
+	h := &TokenHandler{
+		issuer:    issuer,
+		store:     store,
+		extractor: TLSExtractor,
+		clock:     time.Now,
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h, nil
+}
+
+// ServeHTTP issues the token.
+func (h *TokenHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		writeError(w, http.StatusMethodNotAllowed, errInvalidRequest, "token request must be POST")
+		return
+	}
+	if mt, _, err := mime.ParseMediaType(r.Header.Get("Content-Type")); err != nil || mt != "application/x-www-form-urlencoded" {
+		writeError(w, http.StatusBadRequest, errInvalidRequest, "content type must be application/x-www-form-urlencoded")
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		writeError(w, http.StatusBadRequest, errInvalidRequest, "failed to parse the request")
+		return
+	}
+	for name, values := range r.PostForm {
+		if len(values) > 1 {
+			writeError(w, http.StatusBadRequest, errInvalidRequest, "parameter is repeated: "+name)
+			return
+		}
+	}
+
+	if grantType := r.PostForm.Get("grant_type"); grantType != "client_credentials" {
+		writeError(w, http.StatusBadRequest, errUnsupportedGrantType, "")
+		return
+	}
+
+	cert, err := h.extractor.ExtractCertificate(r)
+	if err != nil || len(cert.Certificates) == 0 {
+		writeError(w, http.StatusUnauthorized, errInvalidClient, "client certificate is required")
+		return
+	}
+	client, err := h.authenticate(r.PostForm.Get("client_id"), cert)
+	if err != nil {
+		writeError(w, http.StatusUnauthorized, errInvalidClient, "")
+		return
+	}
+
+	scopes, err := client.GrantScopes(strings.Fields(r.PostForm.Get("scope")))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, errInvalidScope, err.Error())
+		return
+	}
+	scope := strings.Join(scopes, " ")
+
+	claims := mtls_token.RawClaims{
+		"sub":       client.ID,
+		"client_id": client.ID,
+	}
+	if h.iss != "" {
+		claims["iss"] = h.iss
+	}
+	if scope != "" {
+		claims["scope"] = scope
+	}
+	if client.Lifetime > 0 {
+		now := h.clock()
+		claims["iat"] = now.Unix()
+		claims["exp"] = now.Add(client.Lifetime).Unix()
+	}
+
+	// The verification result is kept for the confirmation which requires it.
+	state := &tls.ConnectionState{PeerCertificates: cert.Certificates}
+	if cert.Verified {
+		state.VerifiedChains = [][]*x509.Certificate{cert.Certificates}
+	}
+	token, err := h.issuer.IssueToken(state, claims)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, errServerError, "")
+		return
+	}
+
+	resp := tokenResponse{
+		AccessToken: token,
+		TokenType:   "Bearer",
+		Scope:       scope,
+	}
+	if jwt, err := mtls_token.Parse(token); err == nil {
+		if exp, err := jwt.Claims().GetInt64("exp"); err == nil {
+			resp.ExpiresIn = exp - h.clock().Unix()
+		}
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// authenticate returns the client which the certificate authenticates.
+// If client_id is not in the request, the client is looked up by the certificate.
+func (h *TokenHandler) authenticate(clientID string, cert *ClientCertificate) (*mtls_token.Client, error) {
+	if clientID == "" {
+		return h.store.LookupClientByCertificate(cert.Certificates, cert.Verified)
+	}
+	client, err := h.store.LookupClient(clientID)
+	if err != nil {
+		return nil, err
+	}
+	if err := client.Authenticate(cert.Certificates, cert.Verified); err != nil {
+		return nil, err
+	}
+	return client, nil
+}
+
+// writeError writes the error response.
+// https://tools.ietf.org/html/rfc6749#section-5.2
+func writeError(w http.ResponseWriter, status int, code, description string) {
+	writeJSON(w, status, errorResponse{Error: code, ErrorDescription: description})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Pragma", "no-cache")
+	w.WriteHeader(status)
+	w.Write(b)
+}
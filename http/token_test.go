@@ -0,0 +1,261 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	mtls_token "github.com/kokukuma/mtls-token"
+)
+
+func TestTokenHandler(t *testing.T) {
+	cert := getCertificate(t)
+	other := getCertificate(t)
+	secret := []byte("secret")
+
+	issuer, err := mtls_token.NewIssuer(secret)
+	if err != nil {
+		t.Fatalf("Unexpected error occur: expect:%#v", err)
+	}
+	verifier, err := mtls_token.NewVerifier(secret, mtls_token.WithExpectedIssuers("https://as.example.com"))
+	if err != nil {
+		t.Fatalf("Unexpected error occur: expect:%#v", err)
+	}
+	store, err := mtls_token.NewMemoryClientStore(&mtls_token.Client{
+		ID:         "client",
+		AuthMethod: mtls_token.AuthMethodTLSClientAuth,
+		SubjectDN:  "CN=client",
+		Scopes:     []string{"read", "write"},
+		Lifetime:   5 * time.Minute,
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error occur: expect:%#v", err)
+	}
+	handler, err := NewTokenHandler(issuer, store, WithTokenIssuerName("https://as.example.com"))
+	if err != nil {
+		t.Fatalf("Unexpected error occur: expect:%#v", err)
+	}
+
+	form := func(kv ...string) string {
+		v := url.Values{}
+		for i := 0; i+1 < len(kv); i += 2 {
+			v.Add(kv[i], kv[i+1])
+		}
+		return v.Encode()
+	}
+
+	tcs := map[string]struct {
+		method      string
+		contentType string
+		body        string
+		certs       bool
+		unverified  bool
+		status      int
+		err         string
+		scope       string
+	}{
+		"valid": {
+			body:   form("grant_type", "client_credentials", "client_id", "client", "scope", "read"),
+			certs:  true,
+			status: http.StatusOK,
+			scope:  "read",
+		},
+		"lookup by certificate": {
+			body:   form("grant_type", "client_credentials"),
+			certs:  true,
+			status: http.StatusOK,
+			scope:  "read write",
+		},
+		"scope not allowed": {
+			body:   form("grant_type", "client_credentials", "client_id", "client", "scope", "read admin"),
+			certs:  true,
+			status: http.StatusBadRequest,
+			err:    "invalid_scope",
+		},
+		"get": {
+			method: http.MethodGet,
+			status: http.StatusMethodNotAllowed,
+			err:    "invalid_request",
+		},
+		"content type": {
+			contentType: "application/json",
+			body:        `{"grant_type":"client_credentials"}`,
+			certs:       true,
+			status:      http.StatusBadRequest,
+			err:         "invalid_request",
+		},
+		"repeated parameter": {
+			body:   form("grant_type", "client_credentials", "client_id", "client", "client_id", "client"),
+			certs:  true,
+			status: http.StatusBadRequest,
+			err:    "invalid_request",
+		},
+		"unsupported grant type": {
+			body:   form("grant_type", "password", "client_id", "client"),
+			certs:  true,
+			status: http.StatusBadRequest,
+			err:    "unsupported_grant_type",
+		},
+		"not verified": {
+			body:       form("grant_type", "client_credentials", "client_id", "client"),
+			certs:      true,
+			unverified: true,
+			status:     http.StatusUnauthorized,
+			err:        "invalid_client",
+		},
+		"lookup by certificate not verified": {
+			body:       form("grant_type", "client_credentials"),
+			certs:      true,
+			unverified: true,
+			status:     http.StatusUnauthorized,
+			err:        "invalid_client",
+		},
+		"no certificate": {
+			body:   form("grant_type", "client_credentials", "client_id", "client"),
+			status: http.StatusUnauthorized,
+			err:    "invalid_client",
+		},
+		"unknown client": {
+			body:   form("grant_type", "client_credentials", "client_id", "unknown"),
+			certs:  true,
+			status: http.StatusUnauthorized,
+			err:    "invalid_client",
+		},
+	}
+
+	for name, tc := range tcs {
+		method := tc.method
+		if method == "" {
+			method = http.MethodPost
+		}
+		contentType := tc.contentType
+		if contentType == "" {
+			contentType = "application/x-www-form-urlencoded"
+		}
+		req := httptest.NewRequest(method, "/token", strings.NewReader(tc.body))
+		req.Header.Set("Content-Type", contentType)
+		if tc.certs {
+			req.TLS = verifiedState(cert)
+		}
+		if tc.unverified {
+			req.TLS = tlsState(cert)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		if rec.Code != tc.status {
+			t.Errorf("Unexpected status: %s: expect:%d, given:%d", name, tc.status, rec.Code)
+			continue
+		}
+		if rec.Header().Get("Cache-Control") != "no-store" {
+			t.Errorf("Cache-Control must be no-store: %s", name)
+		}
+		if tc.err != "" {
+			var resp errorResponse
+			if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil || resp.Error != tc.err {
+				t.Errorf("Unexpected error response: %s: expect:%s, given:%s", name, tc.err, rec.Body.String())
+			}
+			continue
+		}
+
+		var resp tokenResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Errorf("Unexpeccted error occur: %s: %#v", name, err)
+			continue
+		}
+		if resp.TokenType != "Bearer" || resp.Scope != tc.scope || resp.ExpiresIn <= 0 || resp.ExpiresIn > 300 {
+			t.Errorf("Unexpected token response: %s: %#v", name, resp)
+		}
+		jwt, err := verifier.Verify(tlsState(cert), resp.AccessToken)
+		if err != nil {
+			t.Errorf("Unexpeccted error occur: %s: %#v", name, err)
+			continue
+		}
+		if sub, _ := jwt.Claims().GetString("sub"); sub != "client" {
+			t.Errorf("Unexpected sub: %s: given:%s", name, sub)
+		}
+		if _, err := verifier.Verify(tlsState(other), resp.AccessToken); !errors.Is(err, mtls_token.ErrVerifyPoP) {
+			t.Errorf("Token must be bound to the client certificate: %s: %#v", name, err)
+		}
+	}
+}
+
+func TestTokenHandlerAuthenticationFailed(t *testing.T) {
+	issuer, err := mtls_token.NewIssuer([]byte("secret"))
+	if err != nil {
+		t.Fatalf("Unexpected error occur: expect:%#v", err)
+	}
+	store, err := mtls_token.NewMemoryClientStore(&mtls_token.Client{
+		ID:         "client",
+		AuthMethod: mtls_token.AuthMethodTLSClientAuth,
+		SubjectDN:  "CN=other",
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error occur: expect:%#v", err)
+	}
+	handler, err := NewTokenHandler(issuer, store)
+	if err != nil {
+		t.Fatalf("Unexpected error occur: expect:%#v", err)
+	}
+
+	body := url.Values{"grant_type": {"client_credentials"}, "client_id": {"client"}}.Encode()
+	req := httptest.NewRequest(http.MethodPost, "/token", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.TLS = tlsState(getCertificate(t))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusUnauthorized || !strings.Contains(rec.Body.String(), "invalid_client") {
+		t.Errorf("Unexpected response: %d %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestTokenHandlerIntermediateConfirmation(t *testing.T) {
+	leaf, other, ca := getCAChain(t)
+	secret := []byte("secret")
+
+	issuer, err := mtls_token.NewIssuer(secret, mtls_token.WithConfirmation(mtls_token.IntermediateConfirmation))
+	if err != nil {
+		t.Fatalf("Unexpected error occur: expect:%#v", err)
+	}
+	verifier, err := mtls_token.NewVerifier(secret, mtls_token.WithAcceptedConfirmations(mtls_token.IntermediateConfirmation))
+	if err != nil {
+		t.Fatalf("Unexpected error occur: expect:%#v", err)
+	}
+	store, err := mtls_token.NewMemoryClientStore(&mtls_token.Client{
+		ID:         "client",
+		AuthMethod: mtls_token.AuthMethodTLSClientAuth,
+		SubjectDN:  "CN=client",
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error occur: expect:%#v", err)
+	}
+	handler, err := NewTokenHandler(issuer, store)
+	if err != nil {
+		t.Fatalf("Unexpected error occur: expect:%#v", err)
+	}
+
+	body := url.Values{"grant_type": {"client_credentials"}, "client_id": {"client"}}.Encode()
+	req := httptest.NewRequest(http.MethodPost, "/token", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.TLS = verifiedState(leaf, ca)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Unexpected response: %d %s", rec.Code, rec.Body.String())
+	}
+	var resp tokenResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Unexpected error occur: expect:%#v", err)
+	}
+	// The token is bound to the CA, so the other certificate issued by it can use the token.
+	if _, err := verifier.Verify(verifiedState(other, ca), resp.AccessToken); err != nil {
+		t.Errorf("Unexpected error occur: %#v", err)
+	}
+}
@@ -0,0 +1,177 @@
+package http
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	mtls_token "github.com/kokukuma/mtls-token"
+)
+
+// Transport is http.RoundTripper which sets the token on Authorization header.
+// If the server rejects the token with invalid_token, new token is fetched
+// from the source and the request is retried once. The rejected token is
+// invalidated if the source has Invalidate, e.g. *mtls_token.CachedTokenSource.
+// Otherwise the source must return new token on every call, or the request
+// is not retried because the same token would be rejected again.
+type Transport struct {
+	base   http.RoundTripper
+	source mtls_token.TokenSource
+	chain  []*x509.Certificate
+}
+
+// TransportOption configures Transport.
+type TransportOption func(*Transport) error
+
+// WithBindingCertificate checks that the token is bound to the client
+// certificate before it is sent. It should be the certificate which base uses.
+// The token bound by x5t#S256, spki#S256 or x5t_ca#S256 is accepted.
+// x5t_ca#S256 needs the intermediate CA certificate in cert.
+func WithBindingCertificate(cert *tls.Certificate) TransportOption {
+	return func(t *Transport) error {
+		if cert == nil || len(cert.Certificate) == 0 {
+			return errors.New("client certificate is empty")
+		}
+		chain := make([]*x509.Certificate, len(cert.Certificate))
+		for i, der := range cert.Certificate {
+			if i == 0 && cert.Leaf != nil {
+				chain[i] = cert.Leaf
+				continue
+			}
+			c, err := x509.ParseCertificate(der)
+			if err != nil {
+				return err
+			}
+			chain[i] = c
+		}
+		t.chain = chain
+		return nil
+	}
+}
+
+var bindingConfirmations = []mtls_token.Confirmation{
+	mtls_token.CertificateConfirmation,
+	mtls_token.PublicKeyConfirmation,
+	mtls_token.IntermediateConfirmation,
+}
+
+// invalidator is TokenSource which can drop the rejected token,
+// e.g. *mtls_token.CachedTokenSource.
+type invalidator interface {
+	Invalidate(token string)
+}
+
+// NewTransport creates Transport.
+// base should be configured with the client certificate. If it is nil,
+// http.DefaultTransport is used.
+func NewTransport(base http.RoundTripper, source mtls_token.TokenSource, opts ...TransportOption) (*Transport, error) {
+	if source == nil {
+		return nil, errors.New("token source is nil")
+	}
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	t := &Transport{base: base, source: source}
+	for _, opt := range opts {
+		if err := opt(t); err != nil {
+			return nil, err
+		}
+	}
+	return t, nil
+}
+
+// RoundTrip sends the request with the token.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	token, err := t.token(req)
+	if err != nil {
+		closeBody(req)
+		return nil, err
+	}
+
+	resp, err := t.base.RoundTrip(withToken(req, token))
+	if err != nil || !isInvalidToken(resp) {
+		return resp, err
+	}
+
+	// The body cannot be sent again.
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return resp, nil
+	}
+	if inv, ok := t.source.(invalidator); ok {
+		inv.Invalidate(token)
+	}
+	rejected := token
+	token, err = t.token(req)
+	if err != nil || token == rejected {
+		return resp, nil
+	}
+
+	retry := withToken(req, "")
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return resp, nil
+		}
+		retry.Body = body
+	}
+	io.Copy(ioutil.Discard, resp.Body)
+	resp.Body.Close()
+
+	retry.Header.Set(authorizationHeader, "Bearer "+token)
+	return t.base.RoundTrip(retry)
+}
+
+func (t *Transport) token(req *http.Request) (string, error) {
+	token, err := t.source.Token(req.Context())
+	if err != nil {
+		return "", err
+	}
+	if t.chain != nil {
+		jwt, err := mtls_token.Parse(token)
+		if err != nil {
+			return "", err
+		}
+		// The chain is the own certificate of the client, so it is trusted.
+		state := &tls.ConnectionState{
+			PeerCertificates: t.chain,
+			VerifiedChains:   [][]*x509.Certificate{t.chain},
+		}
+		if err := mtls_token.VerifyPoP(state, jwt.Claims(), bindingConfirmations...); err != nil {
+			return "", errors.New("token is not bound to the client certificate")
+		}
+	}
+	return token, nil
+}
+
+// withToken clones the request because RoundTripper must not modify it.
+func withToken(req *http.Request, token string) *http.Request {
+	r := req.Clone(req.Context())
+	if token != "" {
+		r.Header.Set(authorizationHeader, "Bearer "+token)
+	}
+	return r
+}
+
+// isInvalidToken checks the error of the resource server.
+// https://tools.ietf.org/html/rfc6750#section-3.1
+func isInvalidToken(resp *http.Response) bool {
+	if resp.StatusCode != http.StatusUnauthorized {
+		return false
+	}
+	for _, v := range resp.Header[http.CanonicalHeaderKey("WWW-Authenticate")] {
+		if strings.Contains(v, `error="invalid_token"`) {
+			return true
+		}
+	}
+	return false
+}
+
+func closeBody(req *http.Request) {
+	if req.Body != nil {
+		req.Body.Close()
+	}
+}
@@ -0,0 +1,161 @@
+package http
+
+import (
+	"context"
+	"crypto/tls"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	mtls_token "github.com/kokukuma/mtls-token"
+)
+
+func TestTransportRetry(t *testing.T) {
+	cert := getCertificate(t)
+	secret := []byte("secret")
+
+	// the first token is issued with the other key, and rejected.
+	issued := 0
+	source := mtls_token.NewCachedTokenSource(mtls_token.TokenSourceFunc(func(ctx context.Context) (string, error) {
+		issued++
+		key := secret
+		if issued == 1 {
+			key = []byte("revoked")
+		}
+		return mtls_token.IssueToken(tlsState(cert), key, mtls_token.RawClaims{}, mtls_token.WithLifetime(time.Hour))
+	}))
+
+	verifier, err := mtls_token.NewVerifier(secret)
+	if err != nil {
+		t.Fatalf("Unexpected error occur: expect:%#v", err)
+	}
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		bodies = append(bodies, string(b))
+		r.TLS = tlsState(cert)
+		Middleware(verifier)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("ok"))
+		})).ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	transport, err := NewTransport(nil, source, WithBindingCertificate(&tls.Certificate{Certificate: [][]byte{cert.Raw}}))
+	if err != nil {
+		t.Fatalf("Unexpected error occur: expect:%#v", err)
+	}
+	client := &http.Client{Transport: transport}
+
+	resp, err := client.Post(server.URL, "text/plain", strings.NewReader("body"))
+	if err != nil {
+		t.Fatalf("Unexpected error occur: expect:%#v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Unexpected status: expect:%#v, given:%#v", http.StatusOK, resp.StatusCode)
+	}
+	if issued != 2 || len(bodies) != 2 || bodies[1] != "body" {
+		t.Errorf("Request must be retried once: issued:%d, bodies:%#v", issued, bodies)
+	}
+
+	// the cached token is used.
+	resp, err = client.Get(server.URL)
+	if err != nil {
+		t.Fatalf("Unexpected error occur: expect:%#v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || issued != 2 {
+		t.Errorf("Cached token must be used: status:%d, issued:%d", resp.StatusCode, issued)
+	}
+}
+
+func TestTransportBindingMismatch(t *testing.T) {
+	cert := getCertificate(t)
+	other := getCertificate(t)
+
+	source := mtls_token.TokenSourceFunc(func(ctx context.Context) (string, error) {
+		return mtls_token.IssueToken(tlsState(other), []byte("secret"), mtls_token.RawClaims{})
+	})
+	transport, err := NewTransport(nil, source, WithBindingCertificate(&tls.Certificate{Certificate: [][]byte{cert.Raw}}))
+	if err != nil {
+		t.Fatalf("Unexpected error occur: expect:%#v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "http://example.com/", nil)
+	if _, err := transport.RoundTrip(req); err == nil {
+		t.Errorf("Should be error occur")
+	}
+}
+
+func TestTransportRetryWithoutInvalidate(t *testing.T) {
+	cert := getCertificate(t)
+	secret := []byte("secret")
+
+	issued := 0
+	source := mtls_token.TokenSourceFunc(func(ctx context.Context) (string, error) {
+		issued++
+		key := secret
+		if issued == 1 {
+			key = []byte("revoked")
+		}
+		return mtls_token.IssueToken(tlsState(cert), key, mtls_token.RawClaims{"n": issued})
+	})
+
+	verifier, err := mtls_token.NewVerifier(secret)
+	if err != nil {
+		t.Fatalf("Unexpected error occur: expect:%#v", err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.TLS = tlsState(cert)
+		Middleware(verifier)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("ok"))
+		})).ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	transport, err := NewTransport(nil, source)
+	if err != nil {
+		t.Fatalf("Unexpected error occur: expect:%#v", err)
+	}
+	resp, err := (&http.Client{Transport: transport}).Get(server.URL)
+	if err != nil {
+		t.Fatalf("Unexpected error occur: expect:%#v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || issued != 2 {
+		t.Errorf("Request must be retried once: status:%d, issued:%d", resp.StatusCode, issued)
+	}
+}
+
+func TestTransportBindingConfirmations(t *testing.T) {
+	cert := getCertificate(t)
+
+	for name, cnf := range map[string]mtls_token.Confirmation{
+		"certificate": mtls_token.CertificateConfirmation,
+		"public key":  mtls_token.PublicKeyConfirmation,
+	} {
+		source := mtls_token.TokenSourceFunc(func(ctx context.Context) (string, error) {
+			return mtls_token.IssueToken(tlsState(cert), []byte("secret"), mtls_token.RawClaims{}, mtls_token.WithConfirmation(cnf))
+		})
+		transport, err := NewTransport(roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody, Request: req}, nil
+		}), source, WithBindingCertificate(&tls.Certificate{Certificate: [][]byte{cert.Raw}}))
+		if err != nil {
+			t.Fatalf("Unexpected error occur: expect:%#v", err)
+		}
+
+		req := httptest.NewRequest(http.MethodGet, "http://example.com/", nil)
+		if _, err := transport.RoundTrip(req); err != nil {
+			t.Errorf("Unexpected error occur: %s: %#v", name, err)
+		}
+	}
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
@@ -0,0 +1,202 @@
+package mtoken
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	defaultType     = "JWT"
+	defaultLifetime = time.Hour
+)
+
+// Issuer creates certificate-bound tokens.
+type Issuer struct {
+	source   SigningKeySource
+	method   Method
+	kid      string
+	typ      string
+	lifetime time.Duration
+	cnf      Confirmation
+}
+
+// IssuerOption configures Issuer.
+type IssuerOption func(*Issuer)
+
+// WithMethod sets the signature method.
+// If it is not set, the method is chosen from the type of the private key.
+func WithMethod(method Method) IssuerOption {
+	return func(i *Issuer) {
+		i.method = method
+	}
+}
+
+// WithKeyID sets kid of the header.
+// If it is not set, the JWK thumbprint of the public key or
+// kid given by SigningKeySource is used.
+func WithKeyID(kid string) IssuerOption {
+	return func(i *Issuer) {
+		i.kid = kid
+	}
+}
+
+// WithType sets typ of the header. The default is "JWT".
+func WithType(typ string) IssuerOption {
+	return func(i *Issuer) {
+		i.typ = typ
+	}
+}
+
+// WithLifetime sets the lifetime used when exp is not in the claims.
+// The default is one hour.
+func WithLifetime(lifetime time.Duration) IssuerOption {
+	return func(i *Issuer) {
+		i.lifetime = lifetime
+	}
+}
+
+// WithConfirmation sets how the token is bound to the client certificate.
+// The default is CertificateConfirmation.
+func WithConfirmation(cnf Confirmation) IssuerOption {
+	return func(i *Issuer) {
+		i.cnf = cnf
+	}
+}
+
+// NewIssuer creates Issuer.
+// If privateKey is SigningKeySource, the key is gotten every time the token is issued.
+func NewIssuer(privateKey interface{}, opts ...IssuerOption) (*Issuer, error) {
+	if privateKey == nil {
+		return nil, ErrKeyPair
+	}
+
+	i := &Issuer{
+		typ:      defaultType,
+		lifetime: defaultLifetime,
+		cnf:      CertificateConfirmation,
+	}
+	for _, opt := range opts {
+		opt(i)
+	}
+
+	switch k := privateKey.(type) {
+	case SigningKeySource:
+		i.source = k
+	default:
+		if i.method == nil {
+			if _, err := methodForKey(privateKey); err != nil {
+				return nil, err
+			}
+		}
+		kid := ""
+		if jwk, err := NewJWK(privateKey); err == nil {
+			kid = jwk.KeyID
+		}
+		i.source = staticSigningKey{kid: kid, key: privateKey}
+	}
+	if i.lifetime <= 0 {
+		return nil, errors.New("lifetime must be positive")
+	}
+	if i.cnf.Member == "" || i.cnf.Thumbprint == nil {
+		return nil, errors.New("confirmation must have member and thumbprint")
+	}
+	return i, nil
+}
+
+// IssueToken create token
+func (i *Issuer) IssueToken(state *tls.ConnectionState, rc RawClaims) (string, error) {
+	if state == nil {
+		return "", ErrMutualTLSConnection
+	}
+	if rc == nil {
+		return "", ErrTokenStruct
+	}
+
+	certs, verified, err := getChainFromTLSState(state)
+	if err != nil {
+		return "", err
+	}
+	if i.cnf.RequireVerifiedChain && !verified {
+		return "", fmt.Errorf("%s requires the verified client certificate chain", i.cnf.Member)
+	}
+	tp, err := i.cnf.Thumbprint(certs)
+	if err != nil {
+		return "", err
+	}
+
+	claims := addTimeClaimsWithLifetime(rc, i.lifetime)
+	claims, err = addConfirmation(claims, i.cnf.Member, tp)
+	if err != nil {
+		return "", err
+	}
+
+	kid, key, err := i.source.SigningKey()
+	if err != nil {
+		return "", err
+	}
+	method := i.method
+	if method == nil {
+		method, err = methodForKey(key)
+		if err != nil {
+			return "", err
+		}
+	}
+	if i.kid != "" {
+		kid = i.kid
+	}
+
+	header := RawHeader{
+		"typ": i.typ,
+	}
+	if kid != "" {
+		header["kid"] = kid
+	}
+	jwt := NewJWT(header, claims, method)
+
+	return jwt.signJWT(key)
+}
+
+// staticSigningKey is SigningKeySource which has one key.
+type staticSigningKey struct {
+	kid string
+	key interface{}
+}
+
+func (s staticSigningKey) SigningKey() (string, interface{}, error) {
+	return s.kid, s.key, nil
+}
+
+// methodForKey returns the default method for the private key.
+// crypto.Signer is inspected by its public key.
+func methodForKey(key interface{}) (Method, error) {
+	if _, ok := key.([]byte); ok {
+		return HS256{}, nil
+	}
+
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, ErrKeyType
+	}
+	switch pub := signer.Public().(type) {
+	case *rsa.PublicKey:
+		return RS256{}, nil
+	case *ecdsa.PublicKey:
+		switch pub.Curve.Params().BitSize {
+		case 256:
+			return ES256{}, nil
+		case 384:
+			return ES384{}, nil
+		case 521:
+			return ES512{}, nil
+		}
+	case ed25519.PublicKey:
+		return EdDSA{}, nil
+	}
+	return nil, ErrKeyType
+}
@@ -0,0 +1,127 @@
+package mtoken
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func getTLSState() *tls.ConnectionState {
+	return &tls.ConnectionState{
+		PeerCertificates: []*x509.Certificate{
+			{Raw: []byte("client certificate")},
+		},
+	}
+}
+
+func TestIssueTokenWithOptions(t *testing.T) {
+	rsaKey, err := getPrivateKey()
+	if err != nil {
+		t.Fatalf("Unexpected error occur: expect:%#v", err)
+	}
+	ecdsaKey, err := getECDSAPrivateKey()
+	if err != nil {
+		t.Fatalf("Unexpected error occur: expect:%#v", err)
+	}
+
+	timeFunc = func() time.Time {
+		return time.Unix(1521644867, 0)
+	}
+	thumbprint := func(key interface{}) string {
+		jwk, err := NewJWK(key)
+		if err != nil {
+			t.Fatalf("Unexpected error occur: expect:%#v", err)
+		}
+		return jwk.KeyID
+	}
+
+	tcs := map[string]struct {
+		key    interface{}
+		opts   []IssuerOption
+		header RawHeader
+		exp    int64
+	}{
+		"rsa default": {
+			key:    rsaKey,
+			header: RawHeader{"alg": "RS256", "typ": "JWT", "kid": thumbprint(rsaKey)},
+			exp:    1521648467,
+		},
+		"ecdsa default": {
+			key:    ecdsaKey,
+			header: RawHeader{"alg": "ES256", "typ": "JWT", "kid": thumbprint(ecdsaKey)},
+			exp:    1521648467,
+		},
+		"hmac default": {
+			key:    []byte("secret"),
+			header: RawHeader{"alg": "HS256", "typ": "JWT"},
+			exp:    1521648467,
+		},
+		"with options": {
+			key: rsaKey,
+			opts: []IssuerOption{
+				WithKeyID("key1"),
+				WithType("at+jwt"),
+				WithLifetime(time.Minute),
+			},
+			header: RawHeader{"alg": "RS256", "typ": "at+jwt", "kid": "key1"},
+			exp:    1521644927,
+		},
+		"with method": {
+			key:    []byte("secret"),
+			opts:   []IssuerOption{WithMethod(HS256{})},
+			header: RawHeader{"alg": "HS256", "typ": "JWT"},
+			exp:    1521648467,
+		},
+	}
+
+	for name, tc := range tcs {
+		tokenStr, err := IssueToken(getTLSState(), tc.key, RawClaims{"iss": "iss"}, tc.opts...)
+		if err != nil {
+			t.Fatalf("Unexpected error occur in %s: expect:%#v", name, err)
+		}
+		jwt, err := Parse(tokenStr)
+		if err != nil {
+			t.Fatalf("Unexpected error occur in %s: expect:%#v", name, err)
+		}
+		if !reflect.DeepEqual(jwt.header, tc.header) {
+			t.Errorf("Unexpected header: %s: expect:%#v, given:%#v", name, tc.header, jwt.header)
+		}
+		if exp, _ := jwt.claims.GetInt64("exp"); exp != tc.exp {
+			t.Errorf("Unexpected exp: %s: expect:%#v, given:%#v", name, tc.exp, exp)
+		}
+	}
+}
+
+func TestNewIssuerFailed(t *testing.T) {
+	tcs := map[string]struct {
+		key  interface{}
+		opts []IssuerOption
+		err  string
+	}{
+		"nil key": {
+			key: nil,
+			err: ErrKeyPair.Error(),
+		},
+		"unknown key": {
+			key: "key",
+			err: "Unexpected key type",
+		},
+		"negative lifetime": {
+			key:  []byte("secret"),
+			opts: []IssuerOption{WithLifetime(-time.Second)},
+			err:  "lifetime must be positive",
+		},
+	}
+
+	for name, tc := range tcs {
+		_, err := NewIssuer(tc.key, tc.opts...)
+		if err == nil {
+			t.Fatalf("Should be error occur in %s", name)
+		}
+		if err.Error() != tc.err {
+			t.Errorf("Unexpeccted error occur: %s: expect:%#v, given:%#v", name, tc.err, err.Error())
+		}
+	}
+}
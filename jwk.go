@@ -0,0 +1,343 @@
+package mtoken
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"sync"
+)
+
+// JWKSPath is the path where the key set is usually published.
+const JWKSPath = "/.well-known/jwks.json"
+
+// JWK is JSON Web Key of public key.
+// *rsa.PublicKey, *ecdsa.PublicKey and ed25519.PublicKey are supported.
+// https://tools.ietf.org/html/rfc7517
+type JWK struct {
+	Key       interface{}
+	KeyID     string
+	Algorithm string
+	Use       string
+}
+
+// rawJWK is the JSON representation of JWK.
+// Private members are never written.
+type rawJWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid,omitempty"`
+	Use string `json:"use,omitempty"`
+	Alg string `json:"alg,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+// NewJWK creates JWK for the key.
+// The public key of crypto.Signer is used, and kid is the JWK thumbprint.
+func NewJWK(key interface{}) (*JWK, error) {
+	if signer, ok := key.(crypto.Signer); ok {
+		key = signer.Public()
+	}
+
+	jwk := &JWK{Key: key}
+	tp, err := jwk.Thumbprint()
+	if err != nil {
+		return nil, err
+	}
+	jwk.KeyID = tp
+	jwk.Use = "sig"
+	return jwk, nil
+}
+
+// Thumbprint returns JWK thumbprint using SHA-256.
+// https://tools.ietf.org/html/rfc7638
+func (k *JWK) Thumbprint() (string, error) {
+	raw, err := k.raw()
+	if err != nil {
+		return "", err
+	}
+
+	// The required members in lexicographic order.
+	var s string
+	switch raw.Kty {
+	case "RSA":
+		s = fmt.Sprintf(`{"e":%q,"kty":%q,"n":%q}`, raw.E, raw.Kty, raw.N)
+	case "EC":
+		s = fmt.Sprintf(`{"crv":%q,"kty":%q,"x":%q,"y":%q}`, raw.Crv, raw.Kty, raw.X, raw.Y)
+	case "OKP":
+		s = fmt.Sprintf(`{"crv":%q,"kty":%q,"x":%q}`, raw.Crv, raw.Kty, raw.X)
+	}
+	sum := sha256.Sum256([]byte(s))
+	return base64.RawURLEncoding.EncodeToString(sum[:]), nil
+}
+
+// MarshalJSON encodes JWK.
+func (k *JWK) MarshalJSON() ([]byte, error) {
+	raw, err := k.raw()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(raw)
+}
+
+// UnmarshalJSON decodes JWK.
+// Private members are ignored.
+func (k *JWK) UnmarshalJSON(b []byte) error {
+	raw := rawJWK{}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+
+	var key interface{}
+	var err error
+	switch raw.Kty {
+	case "RSA":
+		key, err = parseRSAJWK(raw)
+	case "EC":
+		key, err = parseECJWK(raw)
+	case "OKP":
+		key, err = parseOKPJWK(raw)
+	default:
+		err = fmt.Errorf("unsupported kty: %q", raw.Kty)
+	}
+	if err != nil {
+		return err
+	}
+
+	*k = JWK{
+		Key:       key,
+		KeyID:     raw.Kid,
+		Algorithm: raw.Alg,
+		Use:       raw.Use,
+	}
+	return nil
+}
+
+func (k *JWK) raw() (rawJWK, error) {
+	raw := rawJWK{
+		Kid: k.KeyID,
+		Use: k.Use,
+		Alg: k.Algorithm,
+	}
+
+	switch key := k.Key.(type) {
+	case *rsa.PublicKey:
+		raw.Kty = "RSA"
+		raw.N = encodeBigInt(key.N, 0)
+		raw.E = encodeBigInt(big.NewInt(int64(key.E)), 0)
+	case *ecdsa.PublicKey:
+		crv, size, err := curveName(key.Curve)
+		if err != nil {
+			return raw, err
+		}
+		raw.Kty = "EC"
+		raw.Crv = crv
+		raw.X = encodeBigInt(key.X, size)
+		raw.Y = encodeBigInt(key.Y, size)
+	case ed25519.PublicKey:
+		if len(key) != ed25519.PublicKeySize {
+			return raw, ErrKeyType
+		}
+		raw.Kty = "OKP"
+		raw.Crv = "Ed25519"
+		raw.X = base64.RawURLEncoding.EncodeToString(key)
+	default:
+		return raw, ErrKeyType
+	}
+	return raw, nil
+}
+
+func parseRSAJWK(raw rawJWK) (*rsa.PublicKey, error) {
+	n, err := decodeBigInt(raw.N)
+	if err != nil {
+		return nil, err
+	}
+	e, err := decodeBigInt(raw.E)
+	if err != nil {
+		return nil, err
+	}
+	if !e.IsInt64() || e.Int64() > 1<<31-1 || e.Int64() < 3 {
+		return nil, errors.New("invalid RSA exponent")
+	}
+	return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+}
+
+func parseECJWK(raw rawJWK) (*ecdsa.PublicKey, error) {
+	var curve elliptic.Curve
+	switch raw.Crv {
+	case "P-256":
+		curve = elliptic.P256()
+	case "P-384":
+		curve = elliptic.P384()
+	case "P-521":
+		curve = elliptic.P521()
+	default:
+		return nil, fmt.Errorf("unsupported crv: %q", raw.Crv)
+	}
+	x, err := decodeBigInt(raw.X)
+	if err != nil {
+		return nil, err
+	}
+	y, err := decodeBigInt(raw.Y)
+	if err != nil {
+		return nil, err
+	}
+	if !curve.IsOnCurve(x, y) {
+		return nil, errors.New("invalid EC point")
+	}
+	return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+}
+
+func parseOKPJWK(raw rawJWK) (ed25519.PublicKey, error) {
+	if raw.Crv != "Ed25519" {
+		return nil, fmt.Errorf("unsupported crv: %q", raw.Crv)
+	}
+	x, err := base64.RawURLEncoding.DecodeString(raw.X)
+	if err != nil {
+		return nil, err
+	}
+	if len(x) != ed25519.PublicKeySize {
+		return nil, errors.New("invalid Ed25519 public key")
+	}
+	return ed25519.PublicKey(x), nil
+}
+
+func curveName(curve elliptic.Curve) (string, int, error) {
+	switch curve.Params().BitSize {
+	case 256:
+		return "P-256", 32, nil
+	case 384:
+		return "P-384", 48, nil
+	case 521:
+		return "P-521", 66, nil
+	}
+	return "", 0, ErrKeyType
+}
+
+func encodeBigInt(i *big.Int, size int) string {
+	return base64.RawURLEncoding.EncodeToString(padding(i.Bytes(), size))
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(b) == 0 {
+		return nil, errors.New("empty JWK member")
+	}
+	return new(big.Int).SetBytes(b), nil
+}
+
+// KeySet is JWK Set.
+// It can be published as http.Handler.
+type KeySet struct {
+	mu   sync.RWMutex
+	keys []*JWK
+}
+
+// rawKeySet is the JSON representation of KeySet.
+type rawKeySet struct {
+	Keys []json.RawMessage `json:"keys"`
+}
+
+// NewKeySet creates KeySet.
+func NewKeySet(keys ...*JWK) *KeySet {
+	return &KeySet{keys: keys}
+}
+
+// Keys returns the keys in the set.
+func (s *KeySet) Keys() []*JWK {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]*JWK{}, s.keys...)
+}
+
+// SetKeys replaces the keys in the set.
+func (s *KeySet) SetKeys(keys ...*JWK) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys = keys
+}
+
+// LookupKey returns the public key related to kid.
+// If kid is empty, the key is returned only when the set has one key.
+func (s *KeySet) LookupKey(kid string) (interface{}, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if kid == "" {
+		if len(s.keys) == 1 {
+			return s.keys[0].Key, nil
+		}
+		return nil, ErrKeyNotFound
+	}
+	for _, k := range s.keys {
+		if k.KeyID == kid {
+			return k.Key, nil
+		}
+	}
+	return nil, ErrKeyNotFound
+}
+
+// MarshalJSON encodes KeySet.
+func (s *KeySet) MarshalJSON() ([]byte, error) {
+	keys := s.Keys()
+	raw := rawKeySet{Keys: make([]json.RawMessage, 0, len(keys))}
+	for _, k := range keys {
+		b, err := k.MarshalJSON()
+		if err != nil {
+			return nil, err
+		}
+		raw.Keys = append(raw.Keys, b)
+	}
+	return json.Marshal(raw)
+}
+
+// UnmarshalJSON decodes KeySet.
+// Keys which are not supported are skipped.
+// https://tools.ietf.org/html/rfc7517#section-5
+func (s *KeySet) UnmarshalJSON(b []byte) error {
+	raw := rawKeySet{}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+
+	keys := make([]*JWK, 0, len(raw.Keys))
+	for _, r := range raw.Keys {
+		k := &JWK{}
+		if err := k.UnmarshalJSON(r); err != nil {
+			continue
+		}
+		keys = append(keys, k)
+	}
+	s.SetKeys(keys...)
+	return nil
+}
+
+// ServeHTTP publishes the key set.
+func (s *KeySet) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	b, err := s.MarshalJSON()
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(b)
+}
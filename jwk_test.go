@@ -0,0 +1,221 @@
+package mtoken
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+// RFC 8037 Appendix A.3
+func TestJWKThumbprint(t *testing.T) {
+	pub, err := getEd25519PublicKey()
+	if err != nil {
+		t.Fatalf("Unexpected error occur: expect:%#v", err)
+	}
+	jwk, err := NewJWK(pub)
+	if err != nil {
+		t.Fatalf("Unexpected error occur: expect:%#v", err)
+	}
+
+	expect := "kPrK_qmxVWaYVA9wwBF6Iuo3vVzz7TxHCTwXBygrS4k"
+	if jwk.KeyID != expect {
+		t.Errorf("Unexpected thumbprint: expect:%#v, given:%#v", expect, jwk.KeyID)
+	}
+}
+
+func TestJWKMarshalUnmarshal(t *testing.T) {
+	rsaKey, err := getPublicKey()
+	if err != nil {
+		t.Fatalf("Unexpected error occur: expect:%#v", err)
+	}
+	edKey, err := getEd25519PublicKey()
+	if err != nil {
+		t.Fatalf("Unexpected error occur: expect:%#v", err)
+	}
+	p521, _ := ecdsa.GenerateKey(elliptic.P521(), rand.Reader)
+
+	tcs := map[string]struct {
+		key interface{}
+		kty string
+	}{
+		"RSA": {
+			key: rsaKey,
+			kty: "RSA",
+		},
+		"EC": {
+			key: &p521.PublicKey,
+			kty: "EC",
+		},
+		"EC private key": {
+			key: p521,
+			kty: "EC",
+		},
+		"OKP": {
+			key: edKey,
+			kty: "OKP",
+		},
+	}
+
+	for name, tc := range tcs {
+		jwk, err := NewJWK(tc.key)
+		if err != nil {
+			t.Fatalf("Unexpected error occur in %s: expect:%#v", name, err)
+		}
+		b, err := json.Marshal(jwk)
+		if err != nil {
+			t.Fatalf("Unexpected error occur in %s: expect:%#v", name, err)
+		}
+
+		members := map[string]interface{}{}
+		if err := json.Unmarshal(b, &members); err != nil {
+			t.Fatalf("Unexpected error occur in %s: expect:%#v", name, err)
+		}
+		if members["kty"] != tc.kty {
+			t.Errorf("Unexpected kty: %s: expect:%#v, given:%#v", name, tc.kty, members["kty"])
+		}
+		if _, ok := members["d"]; ok {
+			t.Errorf("Private key must not be written: %s", name)
+		}
+
+		actual := &JWK{}
+		if err := json.Unmarshal(b, actual); err != nil {
+			t.Fatalf("Unexpected error occur in %s: expect:%#v", name, err)
+		}
+		if !reflect.DeepEqual(actual, jwk) {
+			t.Errorf("Unexpected jwk: %s: expect:%#v, given:%#v", name, jwk, actual)
+		}
+	}
+}
+
+func TestJWKUnmarshalFailed(t *testing.T) {
+	tcs := map[string]struct {
+		jwk string
+	}{
+		"unknown kty": {
+			jwk: `{"kty":"oct","k":"c2VjcmV0"}`,
+		},
+		"unknown crv": {
+			jwk: `{"kty":"EC","crv":"P-192","x":"AQ","y":"AQ"}`,
+		},
+		"not on curve": {
+			jwk: `{"kty":"EC","crv":"P-256","x":"AQ","y":"AQ"}`,
+		},
+		"empty modulus": {
+			jwk: `{"kty":"RSA","n":"","e":"AQAB"}`,
+		},
+		"short ed25519 key": {
+			jwk: `{"kty":"OKP","crv":"Ed25519","x":"AQ"}`,
+		},
+	}
+
+	for name, tc := range tcs {
+		if err := json.Unmarshal([]byte(tc.jwk), &JWK{}); err == nil {
+			t.Errorf("Should be error occur in %s", name)
+		}
+	}
+}
+
+func TestKeySet(t *testing.T) {
+	rsaKey, err := getPublicKey()
+	if err != nil {
+		t.Fatalf("Unexpected error occur: expect:%#v", err)
+	}
+	edKey, err := getEd25519PublicKey()
+	if err != nil {
+		t.Fatalf("Unexpected error occur: expect:%#v", err)
+	}
+	rsaJWK, _ := NewJWK(rsaKey)
+	edJWK, _ := NewJWK(edKey)
+	ks := NewKeySet(rsaJWK, edJWK)
+
+	rec := httptest.NewRecorder()
+	ks.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, JWKSPath, nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Unexpected status: expect:%#v, given:%#v", http.StatusOK, rec.Code)
+	}
+
+	// The unknown key is skipped.
+	body := rec.Body.String()
+	body = body[:len(body)-2] + `,{"kty":"oct","k":"c2VjcmV0"}]}`
+
+	actual := &KeySet{}
+	if err := json.Unmarshal([]byte(body), actual); err != nil {
+		t.Fatalf("Unexpected error occur: expect:%#v", err)
+	}
+	if len(actual.Keys()) != 2 {
+		t.Fatalf("Unexpected number of keys: expect:%#v, given:%#v", 2, len(actual.Keys()))
+	}
+
+	key, err := actual.LookupKey(edJWK.KeyID)
+	if err != nil {
+		t.Fatalf("Unexpected error occur: expect:%#v", err)
+	}
+	if !reflect.DeepEqual(key, edKey) {
+		t.Errorf("Unexpected key: expect:%#v, given:%#v", edKey, key)
+	}
+	if _, err := actual.LookupKey("unknown"); err != ErrKeyNotFound {
+		t.Errorf("Unexpected error occur: expect:%#v, given:%#v", ErrKeyNotFound, err)
+	}
+	if _, err := actual.LookupKey(""); err != ErrKeyNotFound {
+		t.Errorf("Unexpected error occur: expect:%#v, given:%#v", ErrKeyNotFound, err)
+	}
+}
+
+func TestVerifyWithKeySet(t *testing.T) {
+	rsaKey, err := getPrivateKey()
+	if err != nil {
+		t.Fatalf("Unexpected error occur: expect:%#v", err)
+	}
+	edKey, err := getEd25519PrivateKey()
+	if err != nil {
+		t.Fatalf("Unexpected error occur: expect:%#v", err)
+	}
+	rsaJWK, _ := NewJWK(rsaKey)
+	edJWK, _ := NewJWK(edKey)
+
+	v, err := NewVerifier(NewKeySet(rsaJWK, edJWK))
+	if err != nil {
+		t.Fatalf("Unexpected error occur: expect:%#v", err)
+	}
+
+	state := getTLSState()
+	tcs := map[string]struct {
+		key  interface{}
+		opts []IssuerOption
+		err  error
+	}{
+		"rsa": {
+			key: rsaKey,
+		},
+		"ed25519": {
+			key: edKey,
+		},
+		"unknown kid": {
+			key:  rsaKey,
+			opts: []IssuerOption{WithKeyID("unknown")},
+			err:  ErrKeyNotFound,
+		},
+		"wrong kid": {
+			key:  rsaKey,
+			opts: []IssuerOption{WithKeyID(edJWK.KeyID)},
+			err:  ErrKeyType,
+		},
+	}
+
+	for name, tc := range tcs {
+		tokenStr, err := IssueToken(state, tc.key, RawClaims{"iss": "iss"}, tc.opts...)
+		if err != nil {
+			t.Fatalf("Unexpected error occur in %s: expect:%#v", name, err)
+		}
+		_, err = v.Verify(state, tokenStr)
+		if !errors.Is(err, tc.err) {
+			t.Errorf("Unexpected error occur: %s: expect:%#v, given:%#v", name, tc.err, err)
+		}
+	}
+}
@@ -1,7 +1,6 @@
 package mtoken
 
 import (
-	"crypto"
 	"encoding/base64"
 	"encoding/json"
 	"errors"
@@ -10,10 +9,6 @@ import (
 	"time"
 )
 
-const (
-	alg = crypto.SHA256
-)
-
 var (
 	timeFunc = func() time.Time {
 		return time.Now()
@@ -38,6 +33,16 @@ func NewJWT(header RawHeader, claims RawClaims, method Method) *JWT {
 	}
 }
 
+// Header returns the header of JWT.
+func (j *JWT) Header() RawHeader {
+	return j.header
+}
+
+// Claims returns the claims of JWT.
+func (j *JWT) Claims() RawClaims {
+	return j.claims
+}
+
 // Encoding returns unsafe JWT
 func (j *JWT) Encoding() (string, error) {
 	h, err := marshalEncode(j.header)
@@ -73,32 +78,33 @@ func (j *JWT) signJWT(privateKey interface{}) (string, error) {
 }
 
 // Parse returns JWT from jwtSTring
+// The signature is not verified.
 func Parse(jwtString string) (*JWT, error) {
 	parts := strings.Split(jwtString, ".")
 	if len(parts) <= 1 {
-		return nil, errors.New("invalid jwt format")
+		return nil, newValidationError(ReasonMalformed, errors.New("invalid jwt format"))
 	}
 
 	// header
 	header := RawHeader{}
 	err := decodeUnmarshal(parts[0], &header)
 	if err != nil {
-		return nil, err
+		return nil, newValidationError(ReasonMalformed, err)
 	}
 	alg, err := header.GetString("alg")
 	if err != nil {
-		return nil, err
+		return nil, newValidationError(ReasonMalformed, err)
 	}
 	method, err := ParseMethod(alg)
 	if err != nil {
-		return nil, err
+		return nil, newValidationError(ReasonUnsupportedAlg, err)
 	}
 
 	// payload
 	claims := RawClaims{}
 	err = decodeUnmarshal(parts[1], &claims)
 	if err != nil {
-		return nil, err
+		return nil, newValidationError(ReasonMalformed, err)
 	}
 
 	jwt := NewJWT(
@@ -115,16 +121,22 @@ func Parse(jwtString string) (*JWT, error) {
 func (j *JWT) verifyJWT(key interface{}) error {
 	parts := strings.Split(j.raw, ".")
 	if len(parts) != 3 {
-		return errors.New("invalid token received, token must have 3 parts")
+		return newValidationError(ReasonMalformed, errors.New("invalid token received, token must have 3 parts"))
 	}
 
 	signedContent := parts[0] + "." + parts[1]
 	signatureString, err := base64.RawURLEncoding.DecodeString(parts[2])
 	if err != nil {
-		return err
+		return newValidationError(ReasonMalformed, err)
 	}
 
-	return j.method.Verify(key, signedContent, []byte(signatureString))
+	if err := j.method.Verify(key, signedContent, []byte(signatureString)); err != nil {
+		if errors.Is(err, ErrKeyType) {
+			return newValidationError(ReasonKeyType, err)
+		}
+		return newValidationError(ReasonSignature, err)
+	}
+	return nil
 }
 
 func marshalEncode(d interface{}) (string, error) {
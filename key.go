@@ -2,9 +2,11 @@ package mtoken
 
 import (
 	"crypto/ecdsa"
+	"crypto/ed25519"
 	"crypto/rsa"
 	"crypto/x509"
 	"encoding/pem"
+	"errors"
 	"fmt"
 	"io/ioutil"
 )
@@ -19,19 +21,43 @@ func ReadPublicKey(path string) (interface{}, error) {
 }
 
 // GetPublicKey return public key.
-// Certificate and Publickey can be parsed.
+// Certificate, PKIX public key and PKCS#1 RSA public key can be parsed.
+// The first of them is used and the other PEM blocks are skipped.
+// If bytes is not PEM, it is parsed as DER.
+// *rsa.PublicKey, *ecdsa.PublicKey and ed25519.PublicKey are returned.
 func GetPublicKey(bytes []byte) (interface{}, error) {
-	block, _ := pem.Decode(bytes)
+	block, rest := pem.Decode(bytes)
+	if block == nil {
+		return parsePublicKeyDER(bytes)
+	}
+	for ; block != nil; block, rest = pem.Decode(rest) {
+		switch block.Type {
+		case "CERTIFICATE":
+			cert, err := x509.ParseCertificate(block.Bytes)
+			if err != nil {
+				return nil, err
+			}
+			return cert.PublicKey, nil
+		case "PUBLIC KEY":
+			return x509.ParsePKIXPublicKey(block.Bytes)
+		case "RSA PUBLIC KEY":
+			return x509.ParsePKCS1PublicKey(block.Bytes)
+		}
+	}
+	return nil, ErrKeyNotInPEM
+}
 
-	cert, err := x509.ParseCertificate(block.Bytes)
-	if err == nil {
+func parsePublicKeyDER(der []byte) (interface{}, error) {
+	if cert, err := x509.ParseCertificate(der); err == nil {
 		return cert.PublicKey, nil
 	}
-	pub, err := x509.ParsePKIXPublicKey(block.Bytes)
-	if err != nil {
-		return nil, err
+	if key, err := x509.ParsePKIXPublicKey(der); err == nil {
+		return key, nil
+	}
+	if key, err := x509.ParsePKCS1PublicKey(der); err == nil {
+		return key, nil
 	}
-	return pub, nil
+	return nil, errors.New("public key is neither PEM nor DER")
 }
 
 // ReadPrivateKey returns privatekey.
@@ -43,19 +69,111 @@ func ReadPrivateKey(path string) (interface{}, error) {
 	return GetPrivateKey(bytes)
 }
 
-// GetPrivateKey returns privatekey.
-// *ecdsa.PrivateKey and *rsa.PrivateKey are supported.
-func GetPrivateKey(bytes []byte) (interface{}, error) {
-	block, _ := pem.Decode(bytes)
-	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+// ReadPrivateKeyWithPassword returns privatekey which may be encrypted.
+func ReadPrivateKeyWithPassword(path string, password []byte) (interface{}, error) {
+	bytes, err := ioutil.ReadFile(path)
 	if err != nil {
 		return nil, err
 	}
+	return GetPrivateKeyWithPassword(bytes, password)
+}
 
+// GetPrivateKey returns privatekey.
+// PKCS#8, PKCS#1 RSA and SEC1 EC private key can be parsed.
+// The first of them is used and the other PEM blocks are skipped.
+// If bytes is not PEM, it is parsed as DER.
+// *ecdsa.PrivateKey, *rsa.PrivateKey and ed25519.PrivateKey are supported.
+func GetPrivateKey(bytes []byte) (interface{}, error) {
+	return GetPrivateKeyWithPassword(bytes, nil)
+}
+
+// GetPrivateKeyWithPassword returns privatekey.
+// In addition to GetPrivateKey, encrypted PKCS#8 private key is decrypted with password.
+// https://tools.ietf.org/html/rfc5958#section-3
+func GetPrivateKeyWithPassword(bytes []byte, password []byte) (interface{}, error) {
+	block, rest := pem.Decode(bytes)
+	if block == nil {
+		return parsePrivateKeyDER(bytes)
+	}
+	for ; block != nil; block, rest = pem.Decode(rest) {
+		switch block.Type {
+		case "PRIVATE KEY":
+			key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+			if err != nil {
+				return nil, err
+			}
+			return checkPrivateKey(key)
+		case "RSA PRIVATE KEY", "EC PRIVATE KEY":
+			// Legacy encryption of OpenSSL is insecure.
+			if _, ok := block.Headers["DEK-Info"]; ok {
+				return nil, fmt.Errorf("encrypted %s is not supported, use encrypted PKCS#8", block.Type)
+			}
+			if block.Type == "RSA PRIVATE KEY" {
+				return x509.ParsePKCS1PrivateKey(block.Bytes)
+			}
+			return x509.ParseECPrivateKey(block.Bytes)
+		case "ENCRYPTED PRIVATE KEY":
+			if len(password) == 0 {
+				return nil, ErrEncryptedKey
+			}
+			key, err := parsePKCS8WithPassword(block.Bytes, password)
+			if err != nil {
+				return nil, err
+			}
+			return checkPrivateKey(key)
+		}
+	}
+	return nil, ErrKeyNotInPEM
+}
+
+func parsePrivateKeyDER(der []byte) (interface{}, error) {
+	if key, err := x509.ParsePKCS8PrivateKey(der); err == nil {
+		return checkPrivateKey(key)
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(der); err == nil {
+		return key, nil
+	}
+	if key, err := x509.ParseECPrivateKey(der); err == nil {
+		return key, nil
+	}
+	return nil, errors.New("private key is neither PEM nor DER")
+}
+
+func checkPrivateKey(key interface{}) (interface{}, error) {
 	switch key := key.(type) {
-	case *ecdsa.PrivateKey, *rsa.PrivateKey:
+	case *ecdsa.PrivateKey, *rsa.PrivateKey, ed25519.PrivateKey:
 		return key, nil
 	default:
 		return nil, fmt.Errorf("Found unknown private key type in PKCS#8 wrapping")
 	}
 }
+
+// ReadCertificates returns all certificates in the file.
+func ReadCertificates(path string) ([]*x509.Certificate, error) {
+	bytes, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return GetCertificates(bytes)
+}
+
+// GetCertificates returns all certificates in PEM data, in the same order.
+// It can be used to load the certificate chain or CA bundle.
+// The other PEM blocks are skipped.
+func GetCertificates(bytes []byte) ([]*x509.Certificate, error) {
+	var certs []*x509.Certificate
+	for block, rest := pem.Decode(bytes); block != nil; block, rest = pem.Decode(rest) {
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		certs = append(certs, cert)
+	}
+	if len(certs) == 0 {
+		return nil, errors.New("certificate is not found in PEM data")
+	}
+	return certs, nil
+}
@@ -0,0 +1,313 @@
+package mtoken
+
+import (
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+const (
+	// ECDSA test key encrypted with AES-256-CBC and hmacWithSHA256.
+	encryptedECDSAKeyAES256 = `-----BEGIN ENCRYPTED TESTING KEY-----
+MIHsMFcGCSqGSIb3DQEFDTBKMCkGCSqGSIb3DQEFDDAcBAhkJXkpQMKz2gICCAAw
+DAYIKoZIhvcNAgkFADAdBglghkgBZQMEASoEEIgpDVDJMNsyg1qzybGVEjgEgZBl
+pSrYIOtrFtqYj5WvTWwEopFEd3sJspc2D8COgQpAJrasxwxGH7meigcXWZZC3TQx
+nXUp9m7F50ZBBn/FdrDdsnu79U9YJ4liFCCA3RVNzbEpfro5GnGgtVy3HD1Eekmk
+lbIV+8QQSh/qnM1Zh/lN1YxMeez7TH2p7nxSwgVOGl5HIle+X/mIf91n1E+7xvs=
+-----END ENCRYPTED TESTING KEY-----`
+
+	// ECDSA test key encrypted with AES-128-CBC and hmacWithSHA1.
+	encryptedECDSAKeyAES128 = `-----BEGIN ENCRYPTED TESTING KEY-----
+MIHeMEkGCSqGSIb3DQEFDTA8MBsGCSqGSIb3DQEFDDAOBAgY0uLeRX70+wICCAAw
+HQYJYIZIAWUDBAECBBA93/KBSdznBEbIhiEN9M2FBIGQ9fGDT8UYz4JEe2EcENdQ
+6C0uY+3iKZVhg0Tltsr/qS+5uSbCpHtlM/srF6VcQqIhi9YpqTH+wa0v5sUO8EJp
+/Q4/8/eO9GIywIlFMwOImL5xPtkG7vpBH/DUMw1pwl0+4gGoDj2LV5ijXJuS1Sex
+K3tWEh60PbF/iXuvbZIEXalBlsr4zllfkU5FGtyqwOpa
+-----END ENCRYPTED TESTING KEY-----`
+
+	// ECDSA test key in SEC1 with the parameters which openssl ecparam writes.
+	sec1ECDSAKey = `-----BEGIN EC PARAMETERS-----
+BggqhkjOPQMBBw==
+-----END EC PARAMETERS-----
+-----BEGIN EC TESTING KEY-----
+MHcCAQEEIG659GjSLifFSUS5V76BhbMSXs78Z15spchUMq1OO0YToAoGCCqGSM49
+AwEHoUQDQgAETSRN9ps5S7LRLIkbMta8ryyYv5UGbJzYyjVCGU+sO2T9ytAIg5Kr
+xhGsNAzHLBrDwJknLVcYrHLzViqzkDeOeQ==
+-----END EC TESTING KEY-----`
+)
+
+func getCertificatePEM(t *testing.T, key interface{}, cn string) []byte {
+	cert := createCertificate(t, 1, key.(*ecdsa.PrivateKey), nil, nil, func(c *x509.Certificate) {
+		c.Subject = pkix.Name{CommonName: cn}
+	})
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})
+}
+
+func TestGetPrivateKeyFormats(t *testing.T) {
+	rsaKey, err := getPrivateKey()
+	if err != nil {
+		t.Fatalf("Unexpected error occur: expect:%#v", err)
+	}
+	ecdsaKey, err := getECDSAPrivateKey()
+	if err != nil {
+		t.Fatalf("Unexpected error occur: expect:%#v", err)
+	}
+	pkcs8, err := x509.MarshalPKCS8PrivateKey(ecdsaKey)
+	if err != nil {
+		t.Fatalf("Unexpected error occur: expect:%#v", err)
+	}
+	pkcs1 := x509.MarshalPKCS1PrivateKey(rsaKey.(*rsa.PrivateKey))
+
+	tcs := map[string]struct {
+		data     []byte
+		password string
+		key      interface{}
+	}{
+		"pkcs1": {
+			data: pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: pkcs1}),
+			key:  rsaKey,
+		},
+		"sec1 with parameters": {
+			data: []byte(testingKey(sec1ECDSAKey)),
+			key:  ecdsaKey,
+		},
+		"certificate before key": {
+			data: append(getCertificatePEM(t, ecdsaKey, "client"),
+				pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: pkcs8})...),
+			key: ecdsaKey,
+		},
+		"pkcs8 der": {
+			data: pkcs8,
+			key:  ecdsaKey,
+		},
+		"pkcs1 der": {
+			data: pkcs1,
+			key:  rsaKey,
+		},
+		"encrypted aes256 sha256": {
+			data:     []byte(testingKey(encryptedECDSAKeyAES256)),
+			password: "secret",
+			key:      ecdsaKey,
+		},
+		"encrypted aes128 sha1": {
+			data:     []byte(testingKey(encryptedECDSAKeyAES128)),
+			password: "secret",
+			key:      ecdsaKey,
+		},
+	}
+
+	for name, tc := range tcs {
+		key, err := GetPrivateKeyWithPassword(tc.data, []byte(tc.password))
+		if err != nil {
+			t.Fatalf("Unexpected error occur in %s: expect:%#v", name, err)
+		}
+		if !reflect.DeepEqual(key, tc.key) {
+			t.Errorf("Unexpected key: %s: expect:%#v, given:%#v", name, tc.key, key)
+		}
+	}
+}
+
+// getEncryptedKeyPEM returns PBES2 encrypted data which has the iteration count.
+// The data is not a key because it must fail before decryption.
+func getEncryptedKeyPEM(t *testing.T, iter int) []byte {
+	kdf, err := asn1.Marshal(pbkdf2Params{Salt: []byte("saltsalt"), IterationCount: iter})
+	if err != nil {
+		t.Fatalf("Unexpected error occur: expect:%#v", err)
+	}
+	iv, _ := asn1.Marshal([]byte("0123456789abcdef"))
+	params, err := asn1.Marshal(pbes2Params{
+		KeyDerivationFunc: pkix.AlgorithmIdentifier{Algorithm: oidPBKDF2, Parameters: asn1.RawValue{FullBytes: kdf}},
+		EncryptionScheme:  pkix.AlgorithmIdentifier{Algorithm: oidAES128CBC, Parameters: asn1.RawValue{FullBytes: iv}},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error occur: expect:%#v", err)
+	}
+	der, err := asn1.Marshal(encryptedPrivateKeyInfo{
+		Algorithm:     pkix.AlgorithmIdentifier{Algorithm: oidPBES2, Parameters: asn1.RawValue{FullBytes: params}},
+		EncryptedData: make([]byte, 32),
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error occur: expect:%#v", err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "ENCRYPTED PRIVATE KEY", Bytes: der})
+}
+
+// RFC 6070 Section 2
+func TestPBKDF2RFC6070(t *testing.T) {
+	tcs := map[string]struct {
+		password string
+		salt     string
+		iter     int
+		keyLen   int
+		expect   string
+	}{
+		"1 iteration": {
+			password: "password",
+			salt:     "salt",
+			iter:     1,
+			keyLen:   20,
+			expect:   "0c60c80f961f0e71f3a9b524af6012062fe037a6",
+		},
+		"2 iterations": {
+			password: "password",
+			salt:     "salt",
+			iter:     2,
+			keyLen:   20,
+			expect:   "ea6c014dc72d6f8ccd1ed92ace1d41f0d8de8957",
+		},
+		"4096 iterations": {
+			password: "password",
+			salt:     "salt",
+			iter:     4096,
+			keyLen:   20,
+			expect:   "4b007901b765489abead49d926f721d065a429c1",
+		},
+		"long password and salt": {
+			password: "passwordPASSWORDpassword",
+			salt:     "saltSALTsaltSALTsaltSALTsaltSALTsalt",
+			iter:     4096,
+			keyLen:   25,
+			expect:   "3d2eec4fe41c849b80c8d83662c0e44a8b291a964cf2f07038",
+		},
+	}
+
+	for name, tc := range tcs {
+		params, err := asn1.Marshal(pbkdf2Params{Salt: []byte(tc.salt), IterationCount: tc.iter})
+		if err != nil {
+			t.Fatalf("Unexpected error occur in %s: expect:%#v", name, err)
+		}
+		kdf := pkix.AlgorithmIdentifier{Algorithm: oidPBKDF2, Parameters: asn1.RawValue{FullBytes: params}}
+		key, err := pbkdf2Derive(kdf, []byte(tc.password), tc.keyLen)
+		if err != nil {
+			t.Fatalf("Unexpected error occur in %s: expect:%#v", name, err)
+		}
+		if actual := hex.EncodeToString(key); actual != tc.expect {
+			t.Errorf("Unexpected key: %s: expect:%#v, given:%#v", name, tc.expect, actual)
+		}
+	}
+}
+
+func TestGetPrivateKeyFailed(t *testing.T) {
+	ecdsaKey, err := getECDSAPrivateKey()
+	if err != nil {
+		t.Fatalf("Unexpected error occur: expect:%#v", err)
+	}
+
+	tcs := map[string]struct {
+		data     []byte
+		password string
+		err      error
+	}{
+		"empty": {
+			data: []byte{},
+		},
+		"no key block": {
+			data: getCertificatePEM(t, ecdsaKey, "client"),
+			err:  ErrKeyNotInPEM,
+		},
+		"encrypted without password": {
+			data: []byte(testingKey(encryptedECDSAKeyAES256)),
+			err:  ErrEncryptedKey,
+		},
+		"wrong password": {
+			data:     []byte(testingKey(encryptedECDSAKeyAES256)),
+			password: "wrong",
+			err:      ErrIncorrectPassword,
+		},
+		"too many iterations": {
+			data:     getEncryptedKeyPEM(t, maxPBKDF2Iterations+1),
+			password: "password",
+		},
+		"no iterations": {
+			data:     getEncryptedKeyPEM(t, 0),
+			password: "password",
+		},
+	}
+
+	for name, tc := range tcs {
+		_, err := GetPrivateKeyWithPassword(tc.data, []byte(tc.password))
+		if err == nil {
+			t.Fatalf("Should be error occur in %s", name)
+		}
+		if tc.err != nil && !errors.Is(err, tc.err) {
+			t.Errorf("Unexpeccted error occur: %s: expect:%#v, given:%#v", name, tc.err, err)
+		}
+	}
+}
+
+func TestGetPublicKeyFormats(t *testing.T) {
+	rsaKey, err := getPrivateKey()
+	if err != nil {
+		t.Fatalf("Unexpected error occur: expect:%#v", err)
+	}
+	ecdsaKey, err := getECDSAPrivateKey()
+	if err != nil {
+		t.Fatalf("Unexpected error occur: expect:%#v", err)
+	}
+	rsaPub := &rsaKey.(*rsa.PrivateKey).PublicKey
+	ecdsaPub := &ecdsaKey.(*ecdsa.PrivateKey).PublicKey
+
+	tcs := map[string]struct {
+		data []byte
+		key  interface{}
+	}{
+		"pkcs1": {
+			data: pem.EncodeToMemory(&pem.Block{Type: "RSA PUBLIC KEY", Bytes: x509.MarshalPKCS1PublicKey(rsaPub)}),
+			key:  rsaPub,
+		},
+		"certificate": {
+			data: getCertificatePEM(t, ecdsaKey, "client"),
+			key:  ecdsaPub,
+		},
+		"pkcs1 der": {
+			data: x509.MarshalPKCS1PublicKey(rsaPub),
+			key:  rsaPub,
+		},
+	}
+
+	for name, tc := range tcs {
+		key, err := GetPublicKey(tc.data)
+		if err != nil {
+			t.Fatalf("Unexpected error occur in %s: expect:%#v", name, err)
+		}
+		if !reflect.DeepEqual(key, tc.key) {
+			t.Errorf("Unexpected key: %s: expect:%#v, given:%#v", name, tc.key, key)
+		}
+	}
+
+	if _, err := GetPublicKey([]byte(testingKey(sec1ECDSAKey))); !errors.Is(err, ErrKeyNotInPEM) {
+		t.Errorf("Unexpeccted error occur: expect:%#v, given:%#v", ErrKeyNotInPEM, err)
+	}
+}
+
+func TestGetCertificates(t *testing.T) {
+	ecdsaKey, err := getECDSAPrivateKey()
+	if err != nil {
+		t.Fatalf("Unexpected error occur: expect:%#v", err)
+	}
+	bundle := append(getCertificatePEM(t, ecdsaKey, "leaf"), []byte(testingKey(sec1ECDSAKey)+"\n")...)
+	bundle = append(bundle, getCertificatePEM(t, ecdsaKey, "ca")...)
+
+	certs, err := GetCertificates(bundle)
+	if err != nil {
+		t.Fatalf("Unexpected error occur: expect:%#v", err)
+	}
+	var names []string
+	for _, cert := range certs {
+		names = append(names, cert.Subject.CommonName)
+	}
+	if expect := []string{"leaf", "ca"}; !reflect.DeepEqual(names, expect) {
+		t.Errorf("Unexpected certificates: expect:%#v, given:%#v", expect, names)
+	}
+
+	if _, err := GetCertificates([]byte(testingKey(sec1ECDSAKey))); err == nil {
+		t.Errorf("Should be error occur")
+	}
+}
@@ -0,0 +1,207 @@
+package mtoken
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// KeyManager rotates the signing key.
+// The current key signs the token, and the previous keys stay published
+// for the grace period after the rotation so that the tokens signed by
+// them can be verified until they expire.
+// It can be used as SigningKeySource for Issuer, and as KeySource and
+// the JWKS handler for Verifier.
+type KeyManager struct {
+	mu       sync.RWMutex
+	current  *managedKey
+	previous []*managedKey
+
+	grace         time.Duration
+	interval      time.Duration
+	generate      func() (interface{}, error)
+	onRotate      func(*KeySet)
+	onRotateError func(error)
+	clock         func() time.Time
+
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+type managedKey struct {
+	jwk       *JWK
+	key       interface{}
+	retiredAt time.Time
+}
+
+// KeyManagerOption configures KeyManager.
+type KeyManagerOption func(*KeyManager)
+
+// WithGracePeriod sets how long the previous key stays published.
+// It should be longer than the token lifetime. The default is one hour.
+func WithGracePeriod(grace time.Duration) KeyManagerOption {
+	return func(m *KeyManager) {
+		m.grace = grace
+	}
+}
+
+// WithRotation rotates the key every interval with the key created by generate.
+func WithRotation(interval time.Duration, generate func() (interface{}, error)) KeyManagerOption {
+	return func(m *KeyManager) {
+		m.interval = interval
+		m.generate = generate
+	}
+}
+
+// WithRotateHook sets the function called with the published keys
+// after the rotation. It can be used to republish JWKS.
+func WithRotateHook(hook func(*KeySet)) KeyManagerOption {
+	return func(m *KeyManager) {
+		m.onRotate = hook
+	}
+}
+
+// WithRotateErrorHook sets the function called when the scheduled rotation failed.
+// The current key is kept in that case.
+func WithRotateErrorHook(hook func(error)) KeyManagerOption {
+	return func(m *KeyManager) {
+		m.onRotateError = hook
+	}
+}
+
+// NewKeyManager creates KeyManager whose current key is privateKey.
+// Only asymmetric keys can be managed because the keys are published.
+// If WithRotation is set, the key is rotated in the background until Close is called.
+func NewKeyManager(privateKey interface{}, opts ...KeyManagerOption) (*KeyManager, error) {
+	m := &KeyManager{
+		grace: defaultLifetime,
+		clock: time.Now,
+		stop:  make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+
+	current, err := newManagedKey(privateKey)
+	if err != nil {
+		return nil, err
+	}
+	m.current = current
+
+	if m.generate != nil {
+		if m.interval <= 0 {
+			return nil, errors.New("rotation interval must be positive")
+		}
+		go m.run()
+	}
+	return m, nil
+}
+
+func newManagedKey(privateKey interface{}) (*managedKey, error) {
+	if privateKey == nil {
+		return nil, ErrKeyPair
+	}
+	if _, err := methodForKey(privateKey); err != nil {
+		return nil, err
+	}
+	jwk, err := NewJWK(privateKey)
+	if err != nil {
+		return nil, err
+	}
+	return &managedKey{jwk: jwk, key: privateKey}, nil
+}
+
+// SigningKey returns kid and the current private key.
+func (m *KeyManager) SigningKey() (string, interface{}, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.current.jwk.KeyID, m.current.key, nil
+}
+
+// Rotate makes privateKey the current key.
+// The key used until now is retired and stays published for the grace period.
+func (m *KeyManager) Rotate(privateKey interface{}) error {
+	next, err := newManagedKey(privateKey)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	now := m.clock()
+	m.current.retiredAt = now
+	previous := []*managedKey{}
+	if m.current.jwk.KeyID != next.jwk.KeyID {
+		previous = append(previous, m.current)
+	}
+	for _, k := range m.previous {
+		if k.jwk.KeyID != next.jwk.KeyID && now.Sub(k.retiredAt) < m.grace {
+			previous = append(previous, k)
+		}
+	}
+	m.current = next
+	m.previous = previous
+	m.mu.Unlock()
+
+	if m.onRotate != nil {
+		m.onRotate(m.KeySet())
+	}
+	return nil
+}
+
+// KeySet returns the published keys.
+// They are the current key and the previous keys within the grace period.
+func (m *KeyManager) KeySet() *KeySet {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	now := m.clock()
+	keys := []*JWK{m.current.jwk}
+	for _, k := range m.previous {
+		if now.Sub(k.retiredAt) < m.grace {
+			keys = append(keys, k.jwk)
+		}
+	}
+	return NewKeySet(keys...)
+}
+
+// LookupKey returns the published public key related to kid.
+func (m *KeyManager) LookupKey(kid string) (interface{}, error) {
+	return m.KeySet().LookupKey(kid)
+}
+
+// ServeHTTP publishes the keys as JWKS.
+func (m *KeyManager) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	m.KeySet().ServeHTTP(w, r)
+}
+
+// Close stops the scheduled rotation.
+func (m *KeyManager) Close() {
+	m.stopOnce.Do(func() {
+		close(m.stop)
+	})
+}
+
+func (m *KeyManager) run() {
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-m.stop:
+			return
+		case <-ticker.C:
+			if err := m.rotate(); err != nil && m.onRotateError != nil {
+				m.onRotateError(err)
+			}
+		}
+	}
+}
+
+func (m *KeyManager) rotate() error {
+	key, err := m.generate()
+	if err != nil {
+		return err
+	}
+	return m.Rotate(key)
+}
@@ -0,0 +1,162 @@
+package mtoken
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestKeyManagerRotate(t *testing.T) {
+	key1, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	key2, _ := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	key3, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+
+	var published *KeySet
+	m, err := NewKeyManager(key1,
+		WithGracePeriod(time.Hour),
+		WithRotateHook(func(ks *KeySet) { published = ks }),
+	)
+	if err != nil {
+		t.Fatalf("Unexpected error occur: expect:%#v", err)
+	}
+	now := time.Unix(1521644867, 0)
+	m.clock = func() time.Time { return now }
+	timeFunc = m.clock
+
+	issuer, err := NewIssuer(m, WithLifetime(time.Hour))
+	if err != nil {
+		t.Fatalf("Unexpected error occur: expect:%#v", err)
+	}
+	verifier, err := NewVerifier(m)
+	if err != nil {
+		t.Fatalf("Unexpected error occur: expect:%#v", err)
+	}
+
+	state := getTLSState()
+	token1, err := issuer.IssueToken(state, RawClaims{"iss": "iss"})
+	if err != nil {
+		t.Fatalf("Unexpected error occur: expect:%#v", err)
+	}
+
+	// rotate to the key on another curve.
+	now = now.Add(10 * time.Minute)
+	if err := m.Rotate(key2); err != nil {
+		t.Fatalf("Unexpected error occur: expect:%#v", err)
+	}
+	if published == nil || len(published.Keys()) != 2 {
+		t.Fatalf("Previous key must be published")
+	}
+
+	token2, err := issuer.IssueToken(state, RawClaims{"iss": "iss"})
+	if err != nil {
+		t.Fatalf("Unexpected error occur: expect:%#v", err)
+	}
+	jwt, err := verifier.Verify(state, token2)
+	if err != nil {
+		t.Fatalf("Unexpected error occur: expect:%#v", err)
+	}
+	if alg, _ := jwt.Header().GetString("alg"); alg != "ES384" {
+		t.Errorf("Unexpected alg: expect:%#v, given:%#v", "ES384", alg)
+	}
+
+	// The token signed by the previous key can be verified within the grace period.
+	if _, err := verifier.Verify(state, token1); err != nil {
+		t.Fatalf("Unexpected error occur: expect:%#v", err)
+	}
+
+	// key1 is removed after the grace period.
+	now = now.Add(time.Hour)
+	if err := m.Rotate(key3); err != nil {
+		t.Fatalf("Unexpected error occur: expect:%#v", err)
+	}
+	if len(published.Keys()) != 2 {
+		t.Errorf("Unexpected number of keys: expect:%#v, given:%#v", 2, len(published.Keys()))
+	}
+	if _, err := verifier.Verify(state, token1); !errors.Is(err, ErrKeyNotFound) {
+		t.Errorf("Unexpected error occur: expect:%#v, given:%#v", ErrKeyNotFound, err)
+	}
+}
+
+func TestKeyManagerScheduledRotation(t *testing.T) {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+
+	var mu sync.Mutex
+	rotated := make(chan *KeySet, 1)
+	errs := make(chan error, 1)
+	fail := true
+	generate := func() (interface{}, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if fail {
+			fail = false
+			return nil, errors.New("failed to generate key")
+		}
+		return ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	}
+
+	m, err := NewKeyManager(key,
+		WithRotation(10*time.Millisecond, generate),
+		WithRotateHook(func(ks *KeySet) {
+			select {
+			case rotated <- ks:
+			default:
+			}
+		}),
+		WithRotateErrorHook(func(err error) {
+			select {
+			case errs <- err:
+			default:
+			}
+		}),
+	)
+	if err != nil {
+		t.Fatalf("Unexpected error occur: expect:%#v", err)
+	}
+	defer m.Close()
+
+	select {
+	case <-errs:
+	case <-time.After(time.Second):
+		t.Fatalf("Rotation error must be reported")
+	}
+	select {
+	case ks := <-rotated:
+		if len(ks.Keys()) < 2 {
+			t.Errorf("Previous key must be published")
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Key must be rotated")
+	}
+
+	kid, _, err := m.SigningKey()
+	if err != nil {
+		t.Fatalf("Unexpected error occur: expect:%#v", err)
+	}
+	jwk, _ := NewJWK(key)
+	if kid == jwk.KeyID {
+		t.Errorf("Signing key must be rotated")
+	}
+}
+
+func TestNewKeyManagerFailed(t *testing.T) {
+	tcs := map[string]struct {
+		key interface{}
+	}{
+		"nil": {
+			key: nil,
+		},
+		"hmac": {
+			key: []byte("secret"),
+		},
+	}
+
+	for name, tc := range tcs {
+		if _, err := NewKeyManager(tc.key); err == nil {
+			t.Errorf("Should be error occur in %s", name)
+		}
+	}
+}
@@ -0,0 +1,16 @@
+package mtoken
+
+// KeySource provides the public key used to verify the token.
+// *KeySet, *RemoteKeySet, *KeyManager and *FileKeySource implement it.
+type KeySource interface {
+	// LookupKey returns the public key related to kid.
+	// ErrKeyNotFound is returned if there is no such key.
+	LookupKey(kid string) (interface{}, error)
+}
+
+// SigningKeySource provides the private key used to sign the token.
+// *KeyManager and *FileKeySource implement it.
+type SigningKeySource interface {
+	// SigningKey returns kid and the private key.
+	SigningKey() (kid string, key interface{}, err error)
+}
@@ -0,0 +1,264 @@
+package mtoken
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// WellKnownMetadataPath is the well-known URI of the authorization server metadata.
+// https://tools.ietf.org/html/rfc8414#section-3
+const WellKnownMetadataPath = "/.well-known/oauth-authorization-server"
+
+const maxMetadataSize = 1 << 20
+
+// Metadata is the authorization server metadata.
+// https://tools.ietf.org/html/rfc8414#section-2
+// https://tools.ietf.org/html/rfc8705#section-3.3
+// https://tools.ietf.org/html/rfc8705#section-5
+type Metadata struct {
+	Issuer                            string   `json:"issuer"`
+	TokenEndpoint                     string   `json:"token_endpoint,omitempty"`
+	JWKSURI                           string   `json:"jwks_uri,omitempty"`
+	IntrospectionEndpoint             string   `json:"introspection_endpoint,omitempty"`
+	ScopesSupported                   []string `json:"scopes_supported,omitempty"`
+	ResponseTypesSupported            []string `json:"response_types_supported"`
+	GrantTypesSupported               []string `json:"grant_types_supported,omitempty"`
+	TokenEndpointAuthMethodsSupported []string `json:"token_endpoint_auth_methods_supported,omitempty"`
+
+	// AccessTokenSigningAlgValuesSupported is the algorithms used to sign the token.
+	// It is not registered by RFC 8414.
+	AccessTokenSigningAlgValuesSupported []string `json:"access_token_signing_alg_values_supported,omitempty"`
+
+	TLSClientCertificateBoundAccessTokens bool              `json:"tls_client_certificate_bound_access_tokens"`
+	MTLSEndpointAliases                   map[string]string `json:"mtls_endpoint_aliases,omitempty"`
+}
+
+// MetadataOption configures Metadata.
+type MetadataOption func(*Metadata)
+
+// WithTokenEndpoint sets token_endpoint.
+func WithTokenEndpoint(endpoint string) MetadataOption {
+	return func(m *Metadata) {
+		m.TokenEndpoint = endpoint
+	}
+}
+
+// WithJWKSURI sets jwks_uri which publishes the keys to verify the token.
+func WithJWKSURI(uri string) MetadataOption {
+	return func(m *Metadata) {
+		m.JWKSURI = uri
+	}
+}
+
+// WithIntrospectionEndpoint sets introspection_endpoint.
+func WithIntrospectionEndpoint(endpoint string) MetadataOption {
+	return func(m *Metadata) {
+		m.IntrospectionEndpoint = endpoint
+	}
+}
+
+// WithSupportedScopes sets scopes_supported.
+func WithSupportedScopes(scopes ...string) MetadataOption {
+	return func(m *Metadata) {
+		m.ScopesSupported = append(m.ScopesSupported, scopes...)
+	}
+}
+
+// WithSupportedAlgorithms sets the algorithms used to sign the token.
+// If it is not set, the names of the registered methods which verify the token
+// with a public key are used, because the clients cannot verify the token signed
+// by the shared secret. It must be set if a method other than the builtin ones
+// is registered, since whether the method uses a public key is unknown.
+func WithSupportedAlgorithms(names ...string) MetadataOption {
+	return func(m *Metadata) {
+		m.AccessTokenSigningAlgValuesSupported = append(m.AccessTokenSigningAlgValuesSupported, names...)
+	}
+}
+
+// WithMTLSEndpointAlias sets the endpoint which the client uses with mutual TLS.
+// name is the metadata name of the endpoint, e.g. "token_endpoint".
+// https://tools.ietf.org/html/rfc8705#section-5
+func WithMTLSEndpointAlias(name, endpoint string) MetadataOption {
+	return func(m *Metadata) {
+		if m.MTLSEndpointAliases == nil {
+			m.MTLSEndpointAliases = map[string]string{}
+		}
+		m.MTLSEndpointAliases[name] = endpoint
+	}
+}
+
+// NewMetadata creates the metadata of the issuer.
+// The token is issued by client_credentials grant with tls_client_auth
+// or self_signed_tls_client_auth, and bound to the client certificate.
+func NewMetadata(issuer string, opts ...MetadataOption) (*Metadata, error) {
+	m := &Metadata{
+		Issuer:                                issuer,
+		ResponseTypesSupported:                []string{},
+		GrantTypesSupported:                   []string{"client_credentials"},
+		TokenEndpointAuthMethodsSupported:     []string{AuthMethodTLSClientAuth, AuthMethodSelfSignedTLSClientAuth},
+		TLSClientCertificateBoundAccessTokens: true,
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	if len(m.AccessTokenSigningAlgValuesSupported) == 0 {
+		names, err := asymmetricMethods()
+		if err != nil {
+			return nil, err
+		}
+		m.AccessTokenSigningAlgValuesSupported = names
+	}
+	if err := m.Validate(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// asymmetricMethods returns the names of the registered methods which verify
+// the token with a public key. The method is classified by its type, so an error
+// is returned if a method other than the builtin ones is registered.
+// https://tools.ietf.org/html/rfc7518#section-3.1
+func asymmetricMethods() ([]string, error) {
+	var names []string
+	for _, name := range Methods() {
+		m, _ := LookupMethod(name)
+		switch m.(type) {
+		case RS256, RS384, RS512, PS256, PS384, PS512, ES256, ES384, ES512, EdDSA:
+			names = append(names, name)
+		case HS256, HS384, HS512:
+		default:
+			return nil, fmt.Errorf("supported algorithms must be set for method: %s", name)
+		}
+	}
+	return names, nil
+}
+
+// Validate checks that issuer and the endpoints are absolute https URLs.
+// https://tools.ietf.org/html/rfc8414#section-2
+func (m *Metadata) Validate() error {
+	u, err := url.Parse(m.Issuer)
+	if err != nil || u.Scheme != "https" || u.Host == "" || u.RawQuery != "" || u.Fragment != "" {
+		return fmt.Errorf("invalid issuer: %q", m.Issuer)
+	}
+
+	endpoints := map[string]string{
+		"token_endpoint":         m.TokenEndpoint,
+		"jwks_uri":               m.JWKSURI,
+		"introspection_endpoint": m.IntrospectionEndpoint,
+	}
+	for name, endpoint := range m.MTLSEndpointAliases {
+		endpoints["mtls_endpoint_aliases."+name] = endpoint
+	}
+	for name, endpoint := range endpoints {
+		if endpoint == "" {
+			continue
+		}
+		u, err := url.Parse(endpoint)
+		if err != nil || u.Scheme != "https" || u.Host == "" || u.Fragment != "" {
+			return fmt.Errorf("invalid %s: %q", name, endpoint)
+		}
+	}
+	return nil
+}
+
+// Endpoint returns the endpoint of the metadata name, e.g. "token_endpoint".
+// If mtls is true and the alias is published, the alias is returned.
+// https://tools.ietf.org/html/rfc8705#section-5
+func (m *Metadata) Endpoint(name string, mtls bool) string {
+	if alias, ok := m.MTLSEndpointAliases[name]; mtls && ok {
+		return alias
+	}
+	switch name {
+	case "token_endpoint":
+		return m.TokenEndpoint
+	case "jwks_uri":
+		return m.JWKSURI
+	case "introspection_endpoint":
+		return m.IntrospectionEndpoint
+	}
+	return ""
+}
+
+// ServeHTTP publishes the metadata. It should be served at the URL
+// returned by MetadataURL.
+func (m *Metadata) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	b, err := json.Marshal(m)
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(b)
+}
+
+// MetadataURL returns the URL of the metadata of the issuer.
+// The well-known path is inserted between the host and the path of issuer.
+// https://tools.ietf.org/html/rfc8414#section-3.1
+func MetadataURL(issuer string) (string, error) {
+	u, err := url.Parse(issuer)
+	if err != nil || u.Host == "" {
+		return "", fmt.Errorf("invalid issuer: %q", issuer)
+	}
+	u.Path = WellKnownMetadataPath + strings.TrimSuffix(u.Path, "/")
+	u.RawPath = ""
+	return u.String(), nil
+}
+
+// DiscoverMetadata fetches the metadata of the issuer.
+// issuer in the metadata must be the same as the requested one.
+// If client is nil, http.DefaultClient is used.
+// https://tools.ietf.org/html/rfc8414#section-3.3
+func DiscoverMetadata(ctx context.Context, client *http.Client, issuer string) (*Metadata, error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	metadataURL, err := MetadataURL(issuer)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest(http.MethodGet, metadataURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch metadata: %s", resp.Status)
+	}
+	b, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxMetadataSize))
+	if err != nil {
+		return nil, err
+	}
+
+	m := &Metadata{}
+	if err := json.Unmarshal(b, m); err != nil {
+		return nil, err
+	}
+	if m.Issuer != issuer {
+		return nil, errors.New("issuer of metadata does not match: " + m.Issuer)
+	}
+	if err := m.Validate(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
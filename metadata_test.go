@@ -0,0 +1,165 @@
+package mtoken
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestNewMetadata(t *testing.T) {
+	m, err := NewMetadata("https://as.example.com",
+		WithTokenEndpoint("https://as.example.com/token"),
+		WithJWKSURI("https://as.example.com/jwks"),
+		WithSupportedScopes("read", "write"),
+		WithMTLSEndpointAlias("token_endpoint", "https://mtls.as.example.com/token"),
+	)
+	if err != nil {
+		t.Fatalf("Unexpected error occur: expect:%#v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	m.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, WellKnownMetadataPath, nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Unexpected status: %d", rec.Code)
+	}
+	var doc map[string]interface{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("Unexpected error occur: expect:%#v", err)
+	}
+	if doc["tls_client_certificate_bound_access_tokens"] != true {
+		t.Errorf("tls_client_certificate_bound_access_tokens must be true: %v", doc)
+	}
+	if doc["jwks_uri"] != "https://as.example.com/jwks" {
+		t.Errorf("Unexpected jwks_uri: %v", doc["jwks_uri"])
+	}
+	aliases, _ := doc["mtls_endpoint_aliases"].(map[string]interface{})
+	if aliases["token_endpoint"] != "https://mtls.as.example.com/token" {
+		t.Errorf("Unexpected mtls_endpoint_aliases: %v", doc["mtls_endpoint_aliases"])
+	}
+	algs, _ := doc["access_token_signing_alg_values_supported"].([]interface{})
+	var names []string
+	for _, alg := range algs {
+		names = append(names, alg.(string))
+	}
+	if !contains(names, "ES256") || !contains(names, "EdDSA") || contains(names, "HS256") {
+		t.Errorf("Unexpected algs: %v", algs)
+	}
+
+	if e := m.Endpoint("token_endpoint", true); e != "https://mtls.as.example.com/token" {
+		t.Errorf("Unexpected endpoint: %s", e)
+	}
+	if e := m.Endpoint("token_endpoint", false); e != "https://as.example.com/token" {
+		t.Errorf("Unexpected endpoint: %s", e)
+	}
+	if e := m.Endpoint("jwks_uri", true); e != "https://as.example.com/jwks" {
+		t.Errorf("Unexpected endpoint: %s", e)
+	}
+
+	rec = httptest.NewRecorder()
+	m.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, WellKnownMetadataPath, nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Unexpected status: %d", rec.Code)
+	}
+}
+
+func TestNewMetadataFailed(t *testing.T) {
+	tcs := map[string]struct {
+		issuer string
+		opts   []MetadataOption
+	}{
+		"http issuer":    {issuer: "http://as.example.com"},
+		"issuer query":   {issuer: "https://as.example.com?tenant=a"},
+		"relative":       {issuer: "https://as.example.com", opts: []MetadataOption{WithTokenEndpoint("/token")}},
+		"http alias":     {issuer: "https://as.example.com", opts: []MetadataOption{WithMTLSEndpointAlias("token_endpoint", "http://mtls.as.example.com/token")}},
+		"jwks fragment":  {issuer: "https://as.example.com", opts: []MetadataOption{WithJWKSURI("https://as.example.com/jwks#a")}},
+		"empty issuer":   {issuer: ""},
+		"invalid issuer": {issuer: "https://%zz"},
+	}
+
+	for name, tc := range tcs {
+		if _, err := NewMetadata(tc.issuer, tc.opts...); err == nil {
+			t.Errorf("Error must occur: %s", name)
+		}
+	}
+}
+
+func TestNewMetadataRegisteredMethod(t *testing.T) {
+	RegisterMethod(testMethod{})
+	defer func() {
+		methodsMu.Lock()
+		delete(methods, "X-TEST")
+		methodsMu.Unlock()
+	}()
+
+	// whether X-TEST uses a public key is unknown.
+	if _, err := NewMetadata("https://as.example.com"); err == nil {
+		t.Errorf("Error must occur without supported algorithms")
+	}
+
+	m, err := NewMetadata("https://as.example.com", WithSupportedAlgorithms("ES256", "X-TEST"))
+	if err != nil {
+		t.Fatalf("Unexpected error occur: expect:%#v", err)
+	}
+	if !contains(m.AccessTokenSigningAlgValuesSupported, "X-TEST") {
+		t.Errorf("Unexpected algs: %v", m.AccessTokenSigningAlgValuesSupported)
+	}
+}
+
+func TestMetadataURL(t *testing.T) {
+	tcs := map[string]struct {
+		issuer   string
+		expected string
+	}{
+		"host":           {issuer: "https://as.example.com", expected: "https://as.example.com/.well-known/oauth-authorization-server"},
+		"trailing slash": {issuer: "https://as.example.com/", expected: "https://as.example.com/.well-known/oauth-authorization-server"},
+		"path":           {issuer: "https://as.example.com/tenant", expected: "https://as.example.com/.well-known/oauth-authorization-server/tenant"},
+	}
+
+	for name, tc := range tcs {
+		u, err := MetadataURL(tc.issuer)
+		if err != nil {
+			t.Errorf("Unexpeccted error occur: %s: %#v", name, err)
+			continue
+		}
+		if u != tc.expected {
+			t.Errorf("Unexpected url: %s: expect:%s, given:%s", name, tc.expected, u)
+		}
+	}
+}
+
+func TestDiscoverMetadata(t *testing.T) {
+	var metadata *Metadata
+	mux := http.NewServeMux()
+	mux.HandleFunc(WellKnownMetadataPath+"/", func(w http.ResponseWriter, r *http.Request) {
+		metadata.ServeHTTP(w, r)
+	})
+	srv := httptest.NewTLSServer(mux)
+	defer srv.Close()
+
+	issuer := srv.URL + "/tenant"
+	metadata, err := NewMetadata(issuer, WithTokenEndpoint(srv.URL+"/token"))
+	if err != nil {
+		t.Fatalf("Unexpected error occur: expect:%#v", err)
+	}
+
+	m, err := DiscoverMetadata(context.Background(), srv.Client(), issuer)
+	if err != nil {
+		t.Fatalf("Unexpected error occur: expect:%#v", err)
+	}
+	if m.TokenEndpoint != srv.URL+"/token" || !m.TLSClientCertificateBoundAccessTokens {
+		t.Errorf("Unexpected metadata: %#v", m)
+	}
+
+	// issuer must match.
+	metadata.Issuer = srv.URL + "/other"
+	if _, err := DiscoverMetadata(context.Background(), srv.Client(), issuer); err == nil || !strings.Contains(err.Error(), "issuer") {
+		t.Errorf("Unexpeccted error occur: %#v", err)
+	}
+
+	if _, err := DiscoverMetadata(context.Background(), srv.Client(), srv.URL); err == nil {
+		t.Errorf("Error must occur for unknown issuer")
+	}
+}
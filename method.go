@@ -1,6 +1,11 @@
 package mtoken
 
-import "errors"
+import (
+	"crypto"
+	_ "crypto/sha512" // register SHA-384 and SHA-512
+	"sort"
+	"sync"
+)
 
 // Method is interface for jwt signature.
 type Method interface {
@@ -9,16 +14,71 @@ type Method interface {
 	Verify(interface{}, string, []byte) error
 }
 
+var (
+	methodsMu sync.RWMutex
+	methods   = map[string]Method{}
+)
+
+func init() {
+	for _, m := range []Method{
+		HS256{}, HS384{}, HS512{},
+		RS256{}, RS384{}, RS512{},
+		PS256{}, PS384{}, PS512{},
+		ES256{}, ES384{}, ES512{},
+		EdDSA{},
+	} {
+		RegisterMethod(m)
+	}
+}
+
+// RegisterMethod makes the method available by its name.
+// A method registered with the same name is replaced.
+// It panics if the method is nil or its name is empty or "none".
+func RegisterMethod(method Method) {
+	if method == nil {
+		panic("mtoken: RegisterMethod method is nil")
+	}
+	name := method.Name()
+	if name == "" || name == "none" {
+		panic("mtoken: RegisterMethod invalid alg name " + name)
+	}
+
+	methodsMu.Lock()
+	defer methodsMu.Unlock()
+	methods[name] = method
+}
+
+// LookupMethod returns the method registered with the name.
+func LookupMethod(name string) (Method, bool) {
+	methodsMu.RLock()
+	defer methodsMu.RUnlock()
+	m, ok := methods[name]
+	return m, ok
+}
+
+// Methods returns the sorted names of the registered methods.
+func Methods() []string {
+	methodsMu.RLock()
+	defer methodsMu.RUnlock()
+	names := make([]string, 0, len(methods))
+	for name := range methods {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
 // ParseMethod convert alg name to method.
 func ParseMethod(name string) (Method, error) {
-	switch name {
-	case "HS256":
-		return HS256{}, nil
-	case "RS256":
-		return RS256{}, nil
-	case "ES256":
-		return ES256{}, nil
-	default:
-		return nil, errors.New("Unsupported error")
+	m, ok := LookupMethod(name)
+	if !ok {
+		return nil, ErrUnsupportedAlg
 	}
+	return m, nil
+}
+
+func execHash(hash crypto.Hash, data string) []byte {
+	h := hash.New()
+	h.Write([]byte(data))
+	return h.Sum(nil)
 }
@@ -0,0 +1,118 @@
+package mtoken
+
+import (
+	"sort"
+	"testing"
+)
+
+// testMethod is a method defined outside of the builtin methods.
+type testMethod struct {
+	HS256
+}
+
+func (m testMethod) Name() string {
+	return "X-TEST"
+}
+
+type noneMethod struct {
+	HS256
+}
+
+func (m noneMethod) Name() string {
+	return "none"
+}
+
+func TestLookupMethod(t *testing.T) {
+	tcs := map[string]struct {
+		name  string
+		found bool
+	}{
+		"HS256": {
+			name:  "HS256",
+			found: true,
+		},
+		"PS512": {
+			name:  "PS512",
+			found: true,
+		},
+		"EdDSA": {
+			name:  "EdDSA",
+			found: true,
+		},
+		"none": {
+			name:  "none",
+			found: false,
+		},
+		"empty": {
+			name:  "",
+			found: false,
+		},
+	}
+
+	for name, tc := range tcs {
+		m, ok := LookupMethod(tc.name)
+		if ok != tc.found {
+			t.Errorf("Unexpected result: %s: expect:%#v, given:%#v", name, tc.found, ok)
+			continue
+		}
+		if ok && m.Name() != tc.name {
+			t.Errorf("Unexpected method: %s: expect:%#v, given:%#v", name, tc.name, m.Name())
+		}
+	}
+}
+
+func TestRegisterMethod(t *testing.T) {
+	if _, err := ParseMethod("X-TEST"); err != ErrUnsupportedAlg {
+		t.Fatalf("Unexpected error occur: expect:%#v, given:%#v", ErrUnsupportedAlg, err)
+	}
+
+	RegisterMethod(testMethod{})
+	defer func() {
+		methodsMu.Lock()
+		delete(methods, "X-TEST")
+		methodsMu.Unlock()
+	}()
+
+	secret := []byte("secret")
+	state := getTLSState()
+	tokenStr, err := IssueToken(state, secret, RawClaims{"iss": "iss"}, WithMethod(testMethod{}))
+	if err != nil {
+		t.Fatalf("Unexpected error occur: expect:%#v", err)
+	}
+	jwt, err := DecodeToken(state, tokenStr, secret)
+	if err != nil {
+		t.Fatalf("Unexpected error occur: expect:%#v", err)
+	}
+	if alg, _ := jwt.Header().GetString("alg"); alg != "X-TEST" {
+		t.Errorf("Unexpected alg: expect:%#v, given:%#v", "X-TEST", alg)
+	}
+
+	names := Methods()
+	if !sort.StringsAreSorted(names) || !contains(names, "X-TEST") || !contains(names, "HS256") {
+		t.Errorf("Unexpected methods: %v", names)
+	}
+}
+
+func TestRegisterMethodPanic(t *testing.T) {
+	tcs := map[string]struct {
+		method Method
+	}{
+		"nil": {
+			method: nil,
+		},
+		"none": {
+			method: noneMethod{},
+		},
+	}
+
+	for name, tc := range tcs {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("Should be panic in %s", name)
+				}
+			}()
+			RegisterMethod(tc.method)
+		}()
+	}
+}
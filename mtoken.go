@@ -1,13 +1,10 @@
 package mtoken
 
-import (
-	"crypto/sha256"
-	"crypto/tls"
-	"encoding/base64"
-)
+import "crypto/tls"
 
 // IssueToken create token
-func IssueToken(state *tls.ConnectionState, privateKey interface{}, rc RawClaims) (string, error) {
+// The options are passed to NewIssuer.
+func IssueToken(state *tls.ConnectionState, privateKey interface{}, rc RawClaims, opts ...IssuerOption) (string, error) {
 	if state == nil {
 		return "", ErrMutualTLSConnection
 	}
@@ -18,84 +15,51 @@ func IssueToken(state *tls.ConnectionState, privateKey interface{}, rc RawClaims
 		return "", ErrTokenStruct
 	}
 
-	tp, err := getThumbprintFromTLSState(state)
+	issuer, err := NewIssuer(privateKey, opts...)
 	if err != nil {
 		return "", err
 	}
-
-	claims, err := NewClaims(rc, tp)
-	if err != nil {
-		return "", err
-	}
-
-	// TODO: headerはNewJWT内で作ればいいか.
-
-	// header
-	header := RawHeader{
-		"kid": "sample_key",
-		"typ": "JWT",
-	}
-	// typやalgも指定はできるが, 指定しなくても
-	jwt := NewJWT(header, claims, RS256{})
-
-	return jwt.signJWT(privateKey)
+	return issuer.IssueToken(state, rc)
 }
 
 // DecodeToken is decode token
-func DecodeToken(state *tls.ConnectionState, jwtString string, publicKey interface{}) (*JWT, error) {
+// Only the signature, iat, exp and the proof of possession are verified.
+// Use Verifier to check the other claims.
+// The options are passed to NewVerifier.
+func DecodeToken(state *tls.ConnectionState, jwtString string, publicKey interface{}, opts ...VerifierOption) (*JWT, error) {
 	if state == nil {
 		return nil, ErrMutualTLSConnection
 	}
-	if publicKey == nil {
-		return nil, ErrKeyPair
-	}
 
-	jwt, err := Parse(jwtString)
+	verifier, err := NewVerifier(publicKey, opts...)
 	if err != nil {
 		return nil, err
 	}
+	return verifier.Verify(state, jwtString)
+}
 
-	// verify signature
-	if err := jwt.verifyJWT(publicKey); err != nil {
-		return nil, err
-	}
-
-	// verify token claims
-	if !jwt.claims.VerifyIat() {
-		return nil, ErrTokenIat
+// VerifyPoP checks that the claims are bound to the client certificate of state
+// in the same way as DecodeToken. It is used with the claims which are verified
+// without the client certificate, e.g. the response of the introspection.
+// If cnfs is empty, CertificateConfirmation is accepted.
+func VerifyPoP(state *tls.ConnectionState, claims RawClaims, cnfs ...Confirmation) error {
+	if state == nil {
+		return ErrMutualTLSConnection
 	}
-	if !jwt.claims.VerifyExp() {
-		return nil, ErrTokenExpire
+	if len(cnfs) == 0 {
+		cnfs = []Confirmation{CertificateConfirmation}
 	}
-
-	// proof of possession
-	tp, err := getThumbprintFromTLSState(state)
+	certs, verified, err := getChainFromTLSState(state)
 	if err != nil {
-		return nil, err
+		return newValidationError(ReasonPoPMismatch, err)
 	}
-	if tp != jwt.claims.GetX5tS256() {
-		return nil, ErrVerifyPoP
-	}
-
-	return jwt, nil
+	return verifyConfirmation(claims, certs, verified, cnfs)
 }
 
 func getThumbprintFromTLSState(state *tls.ConnectionState) (string, error) {
-	if state == nil {
-		return "", ErrMutualTLSConnection
-	}
-	PeerCertificates := state.PeerCertificates
-	if PeerCertificates == nil {
-		return "", ErrMutualTLSConnection
-	}
-
-	if len(PeerCertificates) <= 0 {
-		return "", ErrMutualTLSConnection
+	certs, err := getCertificatesFromTLSState(state)
+	if err != nil {
+		return "", err
 	}
-
-	// The first one is the client certificate.
-	cert := PeerCertificates[0]
-	sum := sha256.Sum256(cert.Raw)
-	tp := base64.RawURLEncoding.EncodeToString(sum[:])
-	return tp, nil
+	return CertificateThumbprint(certs)
 }
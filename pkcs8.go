@@ -0,0 +1,173 @@
+package mtoken
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"hash"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// Encrypted PKCS#8 private keys are decrypted with PBES2.
+// PBKDF2 with HMAC-SHA1/SHA-2 and AES-CBC are supported.
+// The key is derived by golang.org/x/crypto/pbkdf2.
+// https://tools.ietf.org/html/rfc5958#section-3
+// https://tools.ietf.org/html/rfc8018#section-6.2
+
+var (
+	oidPBES2  = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 13}
+	oidPBKDF2 = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 12}
+
+	oidHMACWithSHA1   = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 7}
+	oidHMACWithSHA256 = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 9}
+	oidHMACWithSHA384 = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 10}
+	oidHMACWithSHA512 = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 11}
+
+	oidAES128CBC = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 2}
+	oidAES192CBC = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 22}
+	oidAES256CBC = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 42}
+)
+
+// maxPBKDF2Iterations bounds the work to read a key file, which is read
+// again whenever it changes. It is far more than the tools use by default.
+const maxPBKDF2Iterations = 1000000
+
+type encryptedPrivateKeyInfo struct {
+	Algorithm     pkix.AlgorithmIdentifier
+	EncryptedData []byte
+}
+
+type pbes2Params struct {
+	KeyDerivationFunc pkix.AlgorithmIdentifier
+	EncryptionScheme  pkix.AlgorithmIdentifier
+}
+
+type pbkdf2Params struct {
+	Salt           []byte
+	IterationCount int
+	KeyLength      int                      `asn1:"optional"`
+	PRF            pkix.AlgorithmIdentifier `asn1:"optional"`
+}
+
+// decryptPKCS8 returns DER of PKCS#8 private key.
+func decryptPKCS8(der, password []byte) ([]byte, error) {
+	var info encryptedPrivateKeyInfo
+	if rest, err := asn1.Unmarshal(der, &info); err != nil || len(rest) != 0 {
+		return nil, errors.New("invalid encrypted PKCS#8 private key")
+	}
+	if !info.Algorithm.Algorithm.Equal(oidPBES2) {
+		return nil, fmt.Errorf("unsupported encryption algorithm: %s", info.Algorithm.Algorithm)
+	}
+
+	var params pbes2Params
+	if _, err := asn1.Unmarshal(info.Algorithm.Parameters.FullBytes, &params); err != nil {
+		return nil, errors.New("invalid PBES2 parameters")
+	}
+
+	keyLen, block, err := pbes2Cipher(params.EncryptionScheme)
+	if err != nil {
+		return nil, err
+	}
+	var iv []byte
+	if _, err := asn1.Unmarshal(params.EncryptionScheme.Parameters.FullBytes, &iv); err != nil || len(iv) != aes.BlockSize {
+		return nil, errors.New("invalid AES-CBC IV")
+	}
+
+	key, err := pbkdf2Derive(params.KeyDerivationFunc, password, keyLen)
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := block(key)
+	if err != nil {
+		return nil, err
+	}
+	data := info.EncryptedData
+	if len(data) == 0 || len(data)%c.BlockSize() != 0 {
+		return nil, errors.New("invalid encrypted data length")
+	}
+	plain := make([]byte, len(data))
+	cipher.NewCBCDecrypter(c, iv).CryptBlocks(plain, data)
+
+	return unpad(plain, c.BlockSize())
+}
+
+func pbes2Cipher(alg pkix.AlgorithmIdentifier) (int, func([]byte) (cipher.Block, error), error) {
+	switch {
+	case alg.Algorithm.Equal(oidAES128CBC):
+		return 16, aes.NewCipher, nil
+	case alg.Algorithm.Equal(oidAES192CBC):
+		return 24, aes.NewCipher, nil
+	case alg.Algorithm.Equal(oidAES256CBC):
+		return 32, aes.NewCipher, nil
+	}
+	return 0, nil, fmt.Errorf("unsupported encryption scheme: %s", alg.Algorithm)
+}
+
+func pbkdf2Derive(kdf pkix.AlgorithmIdentifier, password []byte, keyLen int) ([]byte, error) {
+	if !kdf.Algorithm.Equal(oidPBKDF2) {
+		return nil, fmt.Errorf("unsupported key derivation function: %s", kdf.Algorithm)
+	}
+	var params pbkdf2Params
+	if _, err := asn1.Unmarshal(kdf.Parameters.FullBytes, &params); err != nil {
+		return nil, errors.New("invalid PBKDF2 parameters")
+	}
+	if params.IterationCount <= 0 || params.IterationCount > maxPBKDF2Iterations {
+		return nil, fmt.Errorf("invalid PBKDF2 iteration count: %d", params.IterationCount)
+	}
+	if params.KeyLength != 0 && params.KeyLength != keyLen {
+		return nil, errors.New("invalid PBKDF2 key length")
+	}
+
+	var h func() hash.Hash
+	switch {
+	case len(params.PRF.Algorithm) == 0, params.PRF.Algorithm.Equal(oidHMACWithSHA1):
+		h = sha1.New
+	case params.PRF.Algorithm.Equal(oidHMACWithSHA256):
+		h = sha256.New
+	case params.PRF.Algorithm.Equal(oidHMACWithSHA384):
+		h = sha512.New384
+	case params.PRF.Algorithm.Equal(oidHMACWithSHA512):
+		h = sha512.New
+	default:
+		return nil, fmt.Errorf("unsupported PBKDF2 PRF: %s", params.PRF.Algorithm)
+	}
+	return pbkdf2.Key(password, params.Salt, params.IterationCount, keyLen, h), nil
+}
+
+// unpad removes PKCS#7 padding.
+// The password is wrong in most cases the padding is invalid.
+func unpad(b []byte, blockSize int) ([]byte, error) {
+	n := int(b[len(b)-1])
+	if n == 0 || n > blockSize || n > len(b) {
+		return nil, ErrIncorrectPassword
+	}
+	for _, v := range b[len(b)-n:] {
+		if int(v) != n {
+			return nil, ErrIncorrectPassword
+		}
+	}
+	return b[:len(b)-n], nil
+}
+
+// parsePKCS8WithPassword parses encrypted PKCS#8 private key.
+func parsePKCS8WithPassword(der, password []byte) (interface{}, error) {
+	plain, err := decryptPKCS8(der, password)
+	if err != nil {
+		return nil, err
+	}
+	key, err := x509.ParsePKCS8PrivateKey(plain)
+	if err != nil {
+		// The padding can be valid by chance with the wrong password.
+		return nil, ErrIncorrectPassword
+	}
+	return key, nil
+}
@@ -0,0 +1,241 @@
+package mtoken
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultJWKSCacheAge        = time.Hour
+	defaultJWKSRefreshInterval = time.Minute
+	defaultJWKSTimeout         = 10 * time.Second
+	maxJWKSSize                = 1 << 20
+)
+
+// RemoteKeySet is KeySource which fetches JWK Set from the issuer.
+// The keys are cached as long as Cache-Control allows and refreshed
+// in the background. An unknown kid makes it fetch the keys again,
+// at most once per the refresh interval. If fetching fails, the last
+// known good keys are used.
+type RemoteKeySet struct {
+	url             string
+	client          *http.Client
+	cacheAge        time.Duration
+	refreshInterval time.Duration
+	clock           func() time.Time
+
+	fetchMu sync.Mutex
+
+	mu        sync.RWMutex
+	keys      *KeySet
+	expiry    time.Time
+	lastFetch time.Time
+
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+// RemoteKeySetOption configures RemoteKeySet.
+type RemoteKeySetOption func(*RemoteKeySet)
+
+// WithHTTPClient sets the client used to fetch the keys.
+func WithHTTPClient(client *http.Client) RemoteKeySetOption {
+	return func(r *RemoteKeySet) {
+		r.client = client
+	}
+}
+
+// WithCacheAge sets how long the keys are cached
+// when the response has no max-age. The default is one hour.
+func WithCacheAge(age time.Duration) RemoteKeySetOption {
+	return func(r *RemoteKeySet) {
+		r.cacheAge = age
+	}
+}
+
+// WithRefreshInterval sets the minimum interval between fetches.
+// The default is one minute.
+func WithRefreshInterval(interval time.Duration) RemoteKeySetOption {
+	return func(r *RemoteKeySet) {
+		r.refreshInterval = interval
+	}
+}
+
+// WithJWKSClock sets the clock used for the cache and the refresh interval.
+// It is used for testing.
+func WithJWKSClock(clock func() time.Time) RemoteKeySetOption {
+	return func(r *RemoteKeySet) {
+		r.clock = clock
+	}
+}
+
+// NewRemoteKeySet creates RemoteKeySet which fetches the keys from url.
+// It refreshes the keys in the background until Close is called.
+func NewRemoteKeySet(url string, opts ...RemoteKeySetOption) *RemoteKeySet {
+	r := &RemoteKeySet{
+		url:             url,
+		client:          &http.Client{Timeout: defaultJWKSTimeout},
+		cacheAge:        defaultJWKSCacheAge,
+		refreshInterval: defaultJWKSRefreshInterval,
+		clock:           time.Now,
+		stop:            make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	go r.run()
+	return r
+}
+
+// Close stops the background refresh.
+func (r *RemoteKeySet) Close() {
+	r.stopOnce.Do(func() {
+		close(r.stop)
+	})
+}
+
+// LookupKey returns the public key related to kid.
+func (r *RemoteKeySet) LookupKey(kid string) (interface{}, error) {
+	keys, expired := r.cached()
+	if keys == nil || expired {
+		err := r.refresh(context.Background(), false)
+		keys, _ = r.cached()
+		if keys == nil {
+			return nil, err
+		}
+	}
+
+	key, err := keys.LookupKey(kid)
+	if err != ErrKeyNotFound {
+		return key, err
+	}
+
+	// The key may have been rotated.
+	if err := r.refresh(context.Background(), false); err != nil {
+		return nil, ErrKeyNotFound
+	}
+	keys, _ = r.cached()
+	return keys.LookupKey(kid)
+}
+
+// Refresh fetches the keys now.
+func (r *RemoteKeySet) Refresh(ctx context.Context) error {
+	return r.refresh(ctx, true)
+}
+
+func (r *RemoteKeySet) cached() (*KeySet, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.keys, !r.clock().Before(r.expiry)
+}
+
+// refresh fetches the keys unless they were fetched within the refresh interval.
+func (r *RemoteKeySet) refresh(ctx context.Context, force bool) error {
+	r.fetchMu.Lock()
+	defer r.fetchMu.Unlock()
+
+	r.mu.RLock()
+	lastFetch := r.lastFetch
+	r.mu.RUnlock()
+	if !force && !lastFetch.IsZero() && r.clock().Sub(lastFetch) < r.refreshInterval {
+		return errors.New("jwks was fetched recently")
+	}
+
+	keys, age, err := r.fetch(ctx)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.lastFetch = r.clock()
+	if err != nil {
+		return err
+	}
+	r.keys = keys
+	r.expiry = r.lastFetch.Add(age)
+	return nil
+}
+
+func (r *RemoteKeySet) fetch(ctx context.Context) (*KeySet, time.Duration, error) {
+	req, err := http.NewRequest(http.MethodGet, r.url, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, 0, fmt.Errorf("failed to fetch jwks: %s", resp.Status)
+	}
+	b, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxJWKSSize))
+	if err != nil {
+		return nil, 0, err
+	}
+
+	keys := &KeySet{}
+	if err := json.Unmarshal(b, keys); err != nil {
+		return nil, 0, err
+	}
+
+	age, ok := parseMaxAge(resp.Header.Get("Cache-Control"))
+	if !ok {
+		age = r.cacheAge
+	}
+	return keys, age, nil
+}
+
+// run refreshes the keys before they expire.
+func (r *RemoteKeySet) run() {
+	for {
+		// The first fetch starts immediately.
+		r.mu.RLock()
+		wait := r.expiry.Sub(r.clock()) - r.refreshInterval
+		fetched := !r.lastFetch.IsZero()
+		r.mu.RUnlock()
+		if fetched && wait < r.refreshInterval {
+			wait = r.refreshInterval
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-r.stop:
+			timer.Stop()
+			return
+		case <-timer.C:
+			r.refresh(context.Background(), false)
+		}
+	}
+}
+
+// parseMaxAge returns max-age of Cache-Control.
+// no-cache and no-store are treated as max-age=0.
+func parseMaxAge(cc string) (time.Duration, bool) {
+	for _, directive := range strings.Split(cc, ",") {
+		directive = strings.ToLower(strings.TrimSpace(directive))
+		switch {
+		case directive == "no-cache", directive == "no-store":
+			return 0, true
+		case strings.HasPrefix(directive, "max-age="):
+			sec, err := strconv.ParseInt(strings.Trim(directive[len("max-age="):], `"`), 10, 64)
+			if err != nil || sec < 0 {
+				return 0, false
+			}
+			return time.Duration(sec) * time.Second, true
+		}
+	}
+	return 0, false
+}
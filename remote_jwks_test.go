@@ -0,0 +1,203 @@
+package mtoken
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// jwksServer stands in for the issuer.
+type jwksServer struct {
+	mu           sync.Mutex
+	keys         *KeySet
+	cacheControl string
+	status       int
+	hits         int
+}
+
+func (s *jwksServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.hits++
+	if s.status != 0 {
+		w.WriteHeader(s.status)
+		return
+	}
+	if s.cacheControl != "" {
+		w.Header().Set("Cache-Control", s.cacheControl)
+	}
+	s.keys.ServeHTTP(w, r)
+}
+
+func (s *jwksServer) set(f func(s *jwksServer)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f(s)
+}
+
+func (s *jwksServer) count() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.hits
+}
+
+// fakeClock is advanced by the test instead of sleeping.
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Unix(1521644867, 0)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func TestRemoteKeySetRotation(t *testing.T) {
+	rsaKey, err := getPrivateKey()
+	if err != nil {
+		t.Fatalf("Unexpected error occur: expect:%#v", err)
+	}
+	edKey, err := getEd25519PrivateKey()
+	if err != nil {
+		t.Fatalf("Unexpected error occur: expect:%#v", err)
+	}
+	rsaJWK, _ := NewJWK(rsaKey)
+	edJWK, _ := NewJWK(edKey)
+
+	s := &jwksServer{keys: NewKeySet(rsaJWK), cacheControl: "public, max-age=3600"}
+	server := httptest.NewServer(s)
+	defer server.Close()
+
+	clock := newFakeClock()
+	ks := NewRemoteKeySet(server.URL, WithRefreshInterval(time.Minute), WithJWKSClock(clock.Now))
+	defer ks.Close()
+	v, err := NewVerifier(ks)
+	if err != nil {
+		t.Fatalf("Unexpected error occur: expect:%#v", err)
+	}
+
+	state := getTLSState()
+	rsaToken, _ := IssueToken(state, rsaKey, RawClaims{"iss": "iss"})
+	edToken, _ := IssueToken(state, edKey, RawClaims{"iss": "iss"})
+
+	// The keys are cached.
+	for i := 0; i < 3; i++ {
+		if _, err := v.Verify(state, rsaToken); err != nil {
+			t.Fatalf("Unexpected error occur: expect:%#v", err)
+		}
+	}
+	if s.count() != 1 {
+		t.Errorf("Unexpected fetch count: expect:%#v, given:%#v", 1, s.count())
+	}
+
+	// Unknown kid does not make it fetch within the refresh interval.
+	if _, err := v.Verify(state, edToken); !errors.Is(err, ErrKeyNotFound) {
+		t.Fatalf("Unexpected error occur: expect:%#v, given:%#v", ErrKeyNotFound, err)
+	}
+	if s.count() != 1 {
+		t.Errorf("Unexpected fetch count: expect:%#v, given:%#v", 1, s.count())
+	}
+
+	// The new key is picked up automatically.
+	s.set(func(s *jwksServer) { s.keys = NewKeySet(rsaJWK, edJWK) })
+	clock.Advance(time.Minute + time.Second)
+	if _, err := v.Verify(state, edToken); err != nil {
+		t.Fatalf("Unexpected error occur: expect:%#v", err)
+	}
+	if s.count() != 2 {
+		t.Errorf("Unexpected fetch count: expect:%#v, given:%#v", 2, s.count())
+	}
+}
+
+func TestRemoteKeySetLastKnownGood(t *testing.T) {
+	rsaKey, err := getPrivateKey()
+	if err != nil {
+		t.Fatalf("Unexpected error occur: expect:%#v", err)
+	}
+	rsaJWK, _ := NewJWK(rsaKey)
+
+	s := &jwksServer{keys: NewKeySet(rsaJWK), cacheControl: "no-cache"}
+	server := httptest.NewServer(s)
+	defer server.Close()
+
+	clock := newFakeClock()
+	ks := NewRemoteKeySet(server.URL, WithRefreshInterval(time.Minute), WithJWKSClock(clock.Now))
+	defer ks.Close()
+
+	if _, err := ks.LookupKey(rsaJWK.KeyID); err != nil {
+		t.Fatalf("Unexpected error occur: expect:%#v", err)
+	}
+
+	// The cached keys are expired, and fetching them again fails.
+	s.set(func(s *jwksServer) { s.status = http.StatusInternalServerError })
+	clock.Advance(time.Minute + time.Second)
+
+	if _, err := ks.LookupKey(rsaJWK.KeyID); err != nil {
+		t.Fatalf("Unexpected error occur: expect:%#v", err)
+	}
+	if s.count() != 2 {
+		t.Errorf("Unexpected fetch count: expect:%#v, given:%#v", 2, s.count())
+	}
+}
+
+func TestRemoteKeySetFetchFailed(t *testing.T) {
+	s := &jwksServer{status: http.StatusNotFound}
+	server := httptest.NewServer(s)
+	defer server.Close()
+
+	ks := NewRemoteKeySet(server.URL)
+	defer ks.Close()
+
+	if _, err := ks.LookupKey("kid"); err == nil {
+		t.Fatalf("Should be error occur")
+	}
+}
+
+func TestParseMaxAge(t *testing.T) {
+	tcs := map[string]struct {
+		cc  string
+		age time.Duration
+		ok  bool
+	}{
+		"empty": {
+			cc: "",
+		},
+		"max-age": {
+			cc:  "public, max-age=600",
+			age: 10 * time.Minute,
+			ok:  true,
+		},
+		"no-cache": {
+			cc: "no-cache",
+			ok: true,
+		},
+		"no-store": {
+			cc: "private, no-store",
+			ok: true,
+		},
+		"invalid": {
+			cc: "max-age=abc",
+		},
+	}
+
+	for name, tc := range tcs {
+		age, ok := parseMaxAge(tc.cc)
+		if age != tc.age || ok != tc.ok {
+			t.Errorf("Unexpected result: %s: expect:%#v %#v, given:%#v %#v", name, tc.age, tc.ok, age, ok)
+		}
+	}
+}
@@ -1,10 +1,9 @@
 package mtoken
 
 import (
+	"crypto"
 	"crypto/rand"
 	"crypto/rsa"
-	"crypto/sha256"
-	"errors"
 )
 
 // RS256 represent Signature algorithm.
@@ -18,25 +17,80 @@ func (r RS256) Name() string {
 
 // Sign creates signature
 func (r RS256) Sign(key interface{}, ss string) ([]byte, error) {
-	k, ok := key.(*rsa.PrivateKey)
-	if !ok {
-		return nil, errors.New("Unexpected key type")
-	}
-	return rsa.SignPKCS1v15(rand.Reader, k, alg, execSha256(ss))
+	return signPKCS1v15(crypto.SHA256, key, ss)
 }
 
 // Verify exec verify signature
 func (r RS256) Verify(key interface{}, ss string, sig []byte) error {
+	return verifyPKCS1v15(crypto.SHA256, key, ss, sig)
+}
 
-	k, ok := key.(*rsa.PublicKey)
+// RS384 represent Signature algorithm.
+type RS384 struct {
+}
+
+// Name returns alg name.
+func (r RS384) Name() string {
+	return "RS384"
+}
+
+// Sign creates signature
+func (r RS384) Sign(key interface{}, ss string) ([]byte, error) {
+	return signPKCS1v15(crypto.SHA384, key, ss)
+}
+
+// Verify exec verify signature
+func (r RS384) Verify(key interface{}, ss string, sig []byte) error {
+	return verifyPKCS1v15(crypto.SHA384, key, ss, sig)
+}
+
+// RS512 represent Signature algorithm.
+type RS512 struct {
+}
+
+// Name returns alg name.
+func (r RS512) Name() string {
+	return "RS512"
+}
+
+// Sign creates signature
+func (r RS512) Sign(key interface{}, ss string) ([]byte, error) {
+	return signPKCS1v15(crypto.SHA512, key, ss)
+}
+
+// Verify exec verify signature
+func (r RS512) Verify(key interface{}, ss string, sig []byte) error {
+	return verifyPKCS1v15(crypto.SHA512, key, ss, sig)
+}
+
+func signPKCS1v15(hash crypto.Hash, key interface{}, ss string) ([]byte, error) {
+	signer, err := rsaSigner(key)
+	if err != nil {
+		return nil, err
+	}
+	return signer.Sign(rand.Reader, execHash(hash, ss), hash)
+}
+
+// rsaSigner returns the key as crypto.Signer which has RSA public key.
+// *rsa.PrivateKey and the keys held by KMS or HSM are supported.
+func rsaSigner(key interface{}) (crypto.Signer, error) {
+	signer, ok := key.(crypto.Signer)
 	if !ok {
-		return errors.New("Unexpected key type")
+		return nil, ErrKeyType
+	}
+	if _, ok := signer.Public().(*rsa.PublicKey); !ok {
+		return nil, ErrKeyType
 	}
-	return rsa.VerifyPKCS1v15(k, alg, execSha256(ss), sig)
+	return signer, nil
 }
 
-func execSha256(data string) []byte {
-	h := sha256.New()
-	h.Write([]byte(data))
-	return h.Sum(nil)
+func verifyPKCS1v15(hash crypto.Hash, key interface{}, ss string, sig []byte) error {
+	k, ok := key.(*rsa.PublicKey)
+	if !ok {
+		return ErrKeyType
+	}
+	if err := rsa.VerifyPKCS1v15(k, hash, execHash(hash, ss), sig); err != nil {
+		return ErrSignature
+	}
+	return nil
 }
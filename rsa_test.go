@@ -1,6 +1,9 @@
 package mtoken
 
 import (
+	"crypto/rsa"
+	"encoding/base64"
+	"math/big"
 	"strings"
 	"testing"
 )
@@ -108,3 +111,86 @@ func TestRS256SignFailed(t *testing.T) {
 		}
 	}
 }
+
+// getRFC7515PrivateKey returns the RSA key of RFC 7515 Appendix A.2.
+func getRFC7515PrivateKey(t *testing.T) *rsa.PrivateKey {
+	decode := func(s string) *big.Int {
+		b, err := base64.RawURLEncoding.DecodeString(s)
+		if err != nil {
+			t.Fatalf("Unexpected error occur: expect:%#v", err)
+		}
+		return new(big.Int).SetBytes(b)
+	}
+
+	key := &rsa.PrivateKey{
+		PublicKey: rsa.PublicKey{
+			N: decode("ofgWCuLjybRlzo0tZWJjNiuSfb4p4fAkd_wWJcyQoTbji9k0l8W26mPddxHmfHQp-Vaw-4qPCJrcS2mJPMEzP1Pt0Bm4d4QlL-yRT-SFd2lZS-pCgNMsD1W_YpRPEwOWvG6b32690r2jZ47soMZo9wGzjb_7OMg0LOL-bSf63kpaSHSXndS5z5rexMdbBYUsLA9e-KXBdQOS-UTo7WTBEMa2R2CapHg665xsmtdVMTBQY4uDZlxvb3qCo5ZwKh9kG4LT6_I5IhlJH7aGhyxXFvUK-DWNmoudF8NAco9_h9iaGNj8q2ethFkMLs91kzk2PAcDTW9gb54h4FRWyuXpoQ"),
+			E: 65537,
+		},
+		D: decode("Eq5xpGnNCivDflJsRQBXHx1hdR1k6Ulwe2JZD50LpXyWPEAeP88vLNO97IjlA7_GQ5sLKMgvfTeXZx9SE-7YwVol2NXOoAJe46sui395IW_GO-pWJ1O0BkTGoVEn2bKVRUCgu-GjBVaYLU6f3l9kJfFNS3E0QbVdxzubSu3Mkqzjkn439X0M_V51gfpRLI9JYanrC4D4qAdGcopV_0ZHHzQlBjudU2QvXt4ehNYTCBr6XCLQUShb1juUO1ZdiYoFaFQT5Tw8bGUl_x_jTj3ccPDVZFD9pIuhLhBOneufuBiB4cS98l2SR_RQyGWSeWjnczT0QU91p1DhOVRuOopznQ"),
+		Primes: []*big.Int{
+			decode("4BzEEOtIpmVdVEZNCqS7baC4crd0pqnRH_5IB3jw3bcxGn6QLvnEtfdUdiYrqBdss1l58BQ3KhooKeQTa9AB0Hw_Py5PJdTJNPY8cQn7ouZ2KKDcmnPGBY5t7yLc1QlQ5xHdwW1VhvKn-nXqhJTBgIPgtldC-KDV5z-y2XDwGUc"),
+			decode("uQPEfgmVtjL0Uyyx88GZFF1fOunH3-7cepKmtH4pxhtCoHqpWmT8YAmZxaewHgHAjLYsp1ZSe7zFYHj7C6ul7TjeLQeZD_YwD66t62wDmpe_HlB-TnBA-njbglfIsRLtXlnDzQkv5dTltRJ11BKBBypeeF6689rjcJIDEz9RWdc"),
+		},
+	}
+	key.Precompute()
+	if err := key.Validate(); err != nil {
+		t.Fatalf("Unexpected error occur: expect:%#v", err)
+	}
+	return key
+}
+
+// RFC 7515 Appendix A.2
+func TestRS256RFC7515(t *testing.T) {
+	key := getRFC7515PrivateKey(t)
+
+	signingInput := "eyJhbGciOiJSUzI1NiJ9.eyJpc3MiOiJqb2UiLA0KICJleHAiOjEzMDA4MTkzODAsDQogImh0dHA6Ly9leGFtcGxlLmNvbS9pc19yb290Ijp0cnVlfQ"
+	expect := "cC4hiUPoj9Eetdgtv3hF80EGrhuB__dzERat0XF9g2VtQgr9PJbu3XOiZj5RZmh7AAuHIm4Bh-0Qc_lF5YKt_O8W2Fp5jujGbds9uJdbF9CUAr7t1dnZcAcQjbKBYNX4BAynRFdiuB--f_nZLgrnbyTyWzO75vRK5h6xBArLIARNPvkSjtQBMHlb1L07Qe7K0GarZRmB_eSN9383LcOLn6_dO--xi12jzDwusC-eOkHWEsqtFZESc6BfI7noOPqvhJ1phCnvWh6IeYI2w9QOYEUipUTI8np6LbgGY9Fs98rqVt5AXLIhWkWywlVmtVrBp0igcN_IoypGlUPQGe77Rw"
+
+	rs := RS256{}
+	sign, err := rs.Sign(key, signingInput)
+	if err != nil {
+		t.Fatalf("Unexpected error occur: expect:%#v", err)
+	}
+	if actual := base64.RawURLEncoding.EncodeToString(sign); actual != expect {
+		t.Errorf("Unexpected signature: expect:%#v, given:%#v", expect, actual)
+	}
+	if err := rs.Verify(&key.PublicKey, signingInput, sign); err != nil {
+		t.Errorf("Unexpected error occur: expect:%#v", err)
+	}
+}
+
+func TestRSSignVerifyHashes(t *testing.T) {
+	key := getRFC7515PrivateKey(t)
+
+	tcs := map[string]struct {
+		method Method
+		other  Method
+	}{
+		"RS256": {
+			method: RS256{},
+			other:  RS512{},
+		},
+		"RS384": {
+			method: RS384{},
+			other:  RS256{},
+		},
+		"RS512": {
+			method: RS512{},
+			other:  RS384{},
+		},
+	}
+
+	for name, tc := range tcs {
+		sign, err := tc.method.Sign(key, "sample")
+		if err != nil {
+			t.Fatalf("Unexpected error occur in %s: expect:%#v", name, err)
+		}
+		if err := tc.method.Verify(&key.PublicKey, "sample", sign); err != nil {
+			t.Fatalf("Unexpected error occur in %s: expect:%#v", name, err)
+		}
+		if err := tc.other.Verify(&key.PublicKey, "sample", sign); err != ErrSignature {
+			t.Errorf("Unexpected error occur in %s: expect:%#v, given:%#v", name, ErrSignature, err)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package mtoken
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+)
+
+// RSASSA-PSS uses the salt whose length is the same as the hash output.
+// https://tools.ietf.org/html/rfc7518#section-3.5
+
+// PS256 represent Signature algorithm.
+type PS256 struct {
+}
+
+// Name returns alg name.
+func (p PS256) Name() string {
+	return "PS256"
+}
+
+// Sign creates signature
+func (p PS256) Sign(key interface{}, ss string) ([]byte, error) {
+	return signPSS(crypto.SHA256, key, ss)
+}
+
+// Verify exec verify signature
+func (p PS256) Verify(key interface{}, ss string, sig []byte) error {
+	return verifyPSS(crypto.SHA256, key, ss, sig)
+}
+
+// PS384 represent Signature algorithm.
+type PS384 struct {
+}
+
+// Name returns alg name.
+func (p PS384) Name() string {
+	return "PS384"
+}
+
+// Sign creates signature
+func (p PS384) Sign(key interface{}, ss string) ([]byte, error) {
+	return signPSS(crypto.SHA384, key, ss)
+}
+
+// Verify exec verify signature
+func (p PS384) Verify(key interface{}, ss string, sig []byte) error {
+	return verifyPSS(crypto.SHA384, key, ss, sig)
+}
+
+// PS512 represent Signature algorithm.
+type PS512 struct {
+}
+
+// Name returns alg name.
+func (p PS512) Name() string {
+	return "PS512"
+}
+
+// Sign creates signature
+func (p PS512) Sign(key interface{}, ss string) ([]byte, error) {
+	return signPSS(crypto.SHA512, key, ss)
+}
+
+// Verify exec verify signature
+func (p PS512) Verify(key interface{}, ss string, sig []byte) error {
+	return verifyPSS(crypto.SHA512, key, ss, sig)
+}
+
+func signPSS(hash crypto.Hash, key interface{}, ss string) ([]byte, error) {
+	signer, err := rsaSigner(key)
+	if err != nil {
+		return nil, err
+	}
+	opts := &rsa.PSSOptions{
+		SaltLength: rsa.PSSSaltLengthEqualsHash,
+		Hash:       hash,
+	}
+	return signer.Sign(rand.Reader, execHash(hash, ss), opts)
+}
+
+func verifyPSS(hash crypto.Hash, key interface{}, ss string, sig []byte) error {
+	k, ok := key.(*rsa.PublicKey)
+	if !ok {
+		return ErrKeyType
+	}
+	opts := &rsa.PSSOptions{
+		SaltLength: rsa.PSSSaltLengthEqualsHash,
+		Hash:       hash,
+	}
+	if err := rsa.VerifyPSS(k, hash, execHash(hash, ss), sig, opts); err != nil {
+		return ErrSignature
+	}
+	return nil
+}
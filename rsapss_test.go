@@ -0,0 +1,154 @@
+package mtoken
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"math/big"
+	"testing"
+)
+
+func TestPSSignVerifySuccess(t *testing.T) {
+	key := getRFC7515PrivateKey(t)
+
+	tcs := map[string]struct {
+		method   Method
+		contents string
+	}{
+		"PS256": {
+			method:   PS256{},
+			contents: "eyJhbGciOiJQUzI1NiJ9.eyJpc3MiOiJqb2UifQ",
+		},
+		"PS384": {
+			method:   PS384{},
+			contents: "eyJhbGciOiJQUzM4NCJ9.eyJpc3MiOiJqb2UifQ",
+		},
+		"PS512": {
+			method:   PS512{},
+			contents: "eyJhbGciOiJQUzUxMiJ9.eyJpc3MiOiJqb2UifQ",
+		},
+	}
+
+	for name, tc := range tcs {
+		sign, err := tc.method.Sign(key, tc.contents)
+		if err != nil {
+			t.Fatalf("Unexpected error occur in %s: expect:%#v", name, err)
+		}
+		if len(sign) != key.Size() {
+			t.Errorf("Unexpected signature length in %s: expect:%#v, given:%#v", name, key.Size(), len(sign))
+		}
+
+		err = tc.method.Verify(&key.PublicKey, tc.contents, sign)
+		if err != nil {
+			t.Fatalf("Unexpected error occur in %s: expect:%#v", name, err)
+		}
+
+		err = tc.method.Verify(&key.PublicKey, tc.contents+".", sign)
+		if !errors.Is(err, ErrSignature) {
+			t.Errorf("Unexpected error occur in %s: expect:%#v, given:%#v", name, ErrSignature, err)
+		}
+	}
+}
+
+func TestPSVerifySaltLength(t *testing.T) {
+	key := getRFC7515PrivateKey(t)
+	contents := "eyJhbGciOiJQUzI1NiJ9.eyJpc3MiOiJqb2UifQ"
+
+	tcs := map[string]struct {
+		saltLength int
+		err        error
+	}{
+		"equals hash": {
+			saltLength: crypto.SHA256.Size(),
+		},
+		"no salt": {
+			saltLength: 0,
+			err:        ErrSignature,
+		},
+		"max salt": {
+			saltLength: rsa.PSSSaltLengthAuto,
+			err:        ErrSignature,
+		},
+	}
+
+	for name, tc := range tcs {
+		opts := &rsa.PSSOptions{SaltLength: tc.saltLength, Hash: crypto.SHA256}
+		sign, err := rsa.SignPSS(rand.Reader, key, crypto.SHA256, execHash(crypto.SHA256, contents), opts)
+		if err != nil {
+			t.Fatalf("Unexpected error occur in %s: expect:%#v", name, err)
+		}
+		err = PS256{}.Verify(&key.PublicKey, contents, sign)
+		if !errors.Is(err, tc.err) {
+			t.Errorf("Unexpected error occur in %s: expect:%#v, given:%#v", name, tc.err, err)
+		}
+	}
+}
+
+func TestPSSignFailed(t *testing.T) {
+	ecdsaKey, err := getECDSAPrivateKey()
+	if err != nil {
+		t.Fatalf("Unexpected error occur: expect:%#v", err)
+	}
+
+	tcs := map[string]struct {
+		privKey  interface{}
+		contents string
+		err      string
+	}{
+		"empty key": {
+			privKey:  "",
+			contents: "sample",
+			err:      "Unexpected key type",
+		},
+		"ecdsa key": {
+			privKey:  ecdsaKey,
+			contents: "sample",
+			err:      "Unexpected key type",
+		},
+	}
+
+	for name, tc := range tcs {
+		_, err := PS256{}.Sign(tc.privKey, tc.contents)
+		if err == nil {
+			t.Fatalf("Should be error occur in %s", name)
+		}
+		if err.Error() != tc.err {
+			t.Errorf("Unexpeccted error occur: %s: expect:%#v, given:%#v", name, tc.err, err.Error())
+		}
+	}
+}
+
+// RFC 7520 Section 4.2. The PSS signature is randomized, so only the
+// published signature is verified with the key of Section 3.4.
+func TestPS384RFC7520(t *testing.T) {
+	n, err := base64.RawURLEncoding.DecodeString("" +
+		"n4EPtAOCc9AlkeQHPzHStgAbgs7bTZLwUBZdR8_KuKPEHLd4rHVTeT" +
+		"-O-XV2jRojdNhxJWTDvNd7nqQ0VEiZQHz_AJmSCpMaJMRBSFKrKb2wqV" +
+		"wGU_NsYOYL-QtiWN2lbzcEe6XC0dApr5ydQLrHqkHHig3RBordaZ6Aj-" +
+		"oBHqFEHYpPe7Tpe-OfVfHd1E6cS6M1FZcD1NNLYD5lFHpPI9bTwJlsde" +
+		"3uhGqC0ZCuEHg8lhzwOHrtIQbS0FVbb9k3-tVTU4fg_3L_vniUFAKwuC" +
+		"LqKnS2BYwdq_mzSnbLY7h_qixoR7jig3__kRhuaxwUkRz5iaiQkqgc5g" +
+		"HdrNP5zw")
+	if err != nil {
+		t.Fatalf("Unexpected error occur: expect:%#v", err)
+	}
+	key := &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: 65537}
+
+	signingInput := "eyJhbGciOiJQUzM4NCIsImtpZCI6ImJpbGJvLmJhZ2dpbnNAaG9iYml0b24uZXhhbXBsZSJ9" +
+		".SXTigJlzIGEgZGFuZ2Vyb3VzIGJ1c2luZXNzLCBGcm9kbywgZ29pbmcgb3V0IHlvdXIgZG9vci4gWW91IHN0ZXAgb250byB0aGUgcm9hZCwgYW5kIGlmIHlvdSBkb24ndCBrZWVwIHlvdXIgZmVldCwgdGhlcmXigJlzIG5vIGtub3dpbmcgd2hlcmUgeW91IG1pZ2h0IGJlIHN3ZXB0IG9mZiB0by4"
+	sign, err := base64.RawURLEncoding.DecodeString("" +
+		"cu22eBqkYDKgIlTpzDXGvaFfz6WGoz7fUDcfT0kkOy42miAh2qyBzk1xEsnk2IpN6-tPid6VrklHkqsGqDqHCdP6O8TTB5dDDItllVo6_1OLPpcbUrhiUSMxbbXUvdvWXzg-UD8biiReQFlfz28zGWVsdiNAUf8ZnyPEgVFn442ZdNqiVJRmBqrYRXe8P_ijQ7p8Vdz0TTrxUeT3lm8d9shnr2lfJT8ImUjvAA2Xez2Mlp8cBE5awDzT0qI0n6uiP1aCN_2_jLAeQTlqRHtfa64QQSUmFAAjVKPbByi7xho0uTOcbH510a6GYmJUAfmWjwZ6oD4ifKo8DYM-X72Eaw")
+	if err != nil {
+		t.Fatalf("Unexpected error occur: expect:%#v", err)
+	}
+
+	if err := (PS384{}).Verify(key, signingInput, sign); err != nil {
+		t.Errorf("Unexpected error occur: expect:%#v", err)
+	}
+	// The other hash must not verify it.
+	if err := (PS256{}).Verify(key, signingInput, sign); !errors.Is(err, ErrSignature) {
+		t.Errorf("Unexpected error occur: expect:%#v, given:%#v", ErrSignature, err)
+	}
+}
@@ -0,0 +1,137 @@
+package mtoken
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"io"
+	"testing"
+)
+
+// fakeSigner is crypto.Signer which hides the type of the private key
+// like a key held by KMS or HSM.
+type fakeSigner struct {
+	signer crypto.Signer
+	calls  int
+}
+
+func (f *fakeSigner) Public() crypto.PublicKey {
+	return f.signer.Public()
+}
+
+func (f *fakeSigner) Sign(rand io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	f.calls++
+	return f.signer.Sign(rand, digest, opts)
+}
+
+func TestSignWithCryptoSigner(t *testing.T) {
+	rsaKey := getRFC7515PrivateKey(t)
+	p256, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	p384, _ := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	edKey, err := getEd25519PrivateKey()
+	if err != nil {
+		t.Fatalf("Unexpected error occur: expect:%#v", err)
+	}
+	edSigner := edKey.(crypto.Signer)
+
+	tcs := map[string]struct {
+		method Method
+		signer crypto.Signer
+	}{
+		"RS256": {
+			method: RS256{},
+			signer: rsaKey,
+		},
+		"PS384": {
+			method: PS384{},
+			signer: rsaKey,
+		},
+		"ES256": {
+			method: ES256{},
+			signer: p256,
+		},
+		"ES384": {
+			method: ES384{},
+			signer: p384,
+		},
+		"EdDSA": {
+			method: EdDSA{},
+			signer: edSigner,
+		},
+	}
+
+	for name, tc := range tcs {
+		signer := &fakeSigner{signer: tc.signer}
+		sign, err := tc.method.Sign(signer, "sample")
+		if err != nil {
+			t.Fatalf("Unexpected error occur in %s: expect:%#v", name, err)
+		}
+		if signer.calls != 1 {
+			t.Errorf("Signer must be called in %s", name)
+		}
+		if err := tc.method.Verify(signer.Public(), "sample", sign); err != nil {
+			t.Errorf("Unexpected error occur in %s: expect:%#v", name, err)
+		}
+	}
+}
+
+func TestSignWithCryptoSignerFailed(t *testing.T) {
+	rsaKey := getRFC7515PrivateKey(t)
+	p256, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+
+	tcs := map[string]struct {
+		method Method
+		signer crypto.Signer
+		err    string
+	}{
+		"ES256 with rsa": {
+			method: ES256{},
+			signer: rsaKey,
+			err:    "Unexpected key type",
+		},
+		"RS256 with ecdsa": {
+			method: RS256{},
+			signer: p256,
+			err:    "Unexpected key type",
+		},
+		"ES384 with P-256": {
+			method: ES384{},
+			signer: p256,
+			err:    "key length must be 384 as ES384",
+		},
+		"EdDSA with ecdsa": {
+			method: EdDSA{},
+			signer: p256,
+			err:    "Unexpected key type",
+		},
+	}
+
+	for name, tc := range tcs {
+		_, err := tc.method.Sign(&fakeSigner{signer: tc.signer}, "sample")
+		if err == nil {
+			t.Fatalf("Should be error occur in %s", name)
+		}
+		if err.Error() != tc.err {
+			t.Errorf("Unexpeccted error occur: %s: expect:%#v, given:%#v", name, tc.err, err.Error())
+		}
+	}
+}
+
+func TestIssueTokenWithCryptoSigner(t *testing.T) {
+	p384, _ := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	signer := &fakeSigner{signer: p384}
+
+	state := getTLSState()
+	tokenStr, err := IssueToken(state, signer, RawClaims{"iss": "iss"})
+	if err != nil {
+		t.Fatalf("Unexpected error occur: expect:%#v", err)
+	}
+	jwt, err := DecodeToken(state, tokenStr, signer.Public())
+	if err != nil {
+		t.Fatalf("Unexpected error occur: expect:%#v", err)
+	}
+	if alg, _ := jwt.Header().GetString("alg"); alg != "ES384" {
+		t.Errorf("Unexpected alg: expect:%#v, given:%#v", "ES384", alg)
+	}
+}
@@ -0,0 +1,107 @@
+package mtoken
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+)
+
+// ThumbprintFunc returns the thumbprint of the client certificate chain.
+// certs[0] is the client certificate and the rest are its issuers.
+type ThumbprintFunc func(certs []*x509.Certificate) (string, error)
+
+// Confirmation is how the token is bound to the client certificate.
+// The thumbprint is written to Member of cnf when the token is issued,
+// and it is compared with the thumbprint of the client certificate when
+// the token is verified.
+// https://tools.ietf.org/html/rfc8705#section-3.1
+type Confirmation struct {
+	Member     string
+	Thumbprint ThumbprintFunc
+
+	// RequireVerifiedChain requires the chain verified by the TLS handshake.
+	// It is set when Thumbprint trusts the issuers in the chain, because the
+	// client can send any certificates after its own one.
+	RequireVerifiedChain bool
+}
+
+var (
+	// CertificateConfirmation binds the token to the client certificate.
+	// It is x5t#S256 defined by RFC 8705, and used by default.
+	CertificateConfirmation = Confirmation{Member: "x5t#S256", Thumbprint: CertificateThumbprint}
+
+	// PublicKeyConfirmation binds the token to the public key of the client certificate.
+	// The token is still valid after the certificate is renewed with the same key.
+	PublicKeyConfirmation = Confirmation{Member: "spki#S256", Thumbprint: PublicKeyThumbprint}
+
+	// IntermediateConfirmation binds the token to the CA which issued the client certificate.
+	// It is for the clients whose certificates are rotated more often than the token.
+	// Note that every client certificate issued by the CA can use the token.
+	// The chain must be verified by the TLS handshake, so tls.Config.ClientAuth must
+	// be tls.VerifyClientCertIfGiven or tls.RequireAndVerifyClientCert.
+	IntermediateConfirmation = Confirmation{Member: "x5t_ca#S256", Thumbprint: IntermediateThumbprint, RequireVerifiedChain: true}
+)
+
+// CertificateThumbprint returns SHA-256 thumbprint of the client certificate.
+func CertificateThumbprint(certs []*x509.Certificate) (string, error) {
+	if len(certs) == 0 {
+		return "", ErrMutualTLSConnection
+	}
+	return encodeThumbprint(certs[0].Raw), nil
+}
+
+// PublicKeyThumbprint returns SHA-256 thumbprint of SubjectPublicKeyInfo of the client certificate.
+func PublicKeyThumbprint(certs []*x509.Certificate) (string, error) {
+	if len(certs) == 0 {
+		return "", ErrMutualTLSConnection
+	}
+	if len(certs[0].RawSubjectPublicKeyInfo) == 0 {
+		return "", errors.New("client certificate has no public key")
+	}
+	return encodeThumbprint(certs[0].RawSubjectPublicKeyInfo), nil
+}
+
+// IntermediateThumbprint returns SHA-256 thumbprint of the issuer of the client certificate.
+// The client certificate must be signed by certs[1].
+func IntermediateThumbprint(certs []*x509.Certificate) (string, error) {
+	if len(certs) == 0 {
+		return "", ErrMutualTLSConnection
+	}
+	if len(certs) < 2 {
+		return "", errors.New("intermediate CA certificate is not found")
+	}
+	if err := certs[0].CheckSignatureFrom(certs[1]); err != nil {
+		return "", fmt.Errorf("client certificate is not issued by the intermediate CA: %v", err)
+	}
+	return encodeThumbprint(certs[1].Raw), nil
+}
+
+func encodeThumbprint(b []byte) string {
+	sum := sha256.Sum256(b)
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// getCertificatesFromTLSState returns the client certificate chain.
+// The verified chain is preferred because the client may not send the intermediates.
+func getCertificatesFromTLSState(state *tls.ConnectionState) ([]*x509.Certificate, error) {
+	certs, _, err := getChainFromTLSState(state)
+	return certs, err
+}
+
+// getChainFromTLSState returns the client certificate chain and whether
+// it is verified by the TLS handshake.
+func getChainFromTLSState(state *tls.ConnectionState) ([]*x509.Certificate, bool, error) {
+	if state == nil {
+		return nil, false, ErrMutualTLSConnection
+	}
+	if len(state.VerifiedChains) > 0 && len(state.VerifiedChains[0]) > 0 {
+		return state.VerifiedChains[0], true, nil
+	}
+	if len(state.PeerCertificates) == 0 {
+		return nil, false, ErrMutualTLSConnection
+	}
+	return state.PeerCertificates, false, nil
+}
@@ -0,0 +1,194 @@
+package mtoken
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+)
+
+// createCertificate creates the certificate signed by parent, or the self-signed
+// one if parent is nil. opts change the subject and SANs of the template.
+func createCertificate(t *testing.T, serial int64, key *ecdsa.PrivateKey, parent *x509.Certificate, parentKey *ecdsa.PrivateKey, opts ...func(*x509.Certificate)) *x509.Certificate {
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(serial),
+		Subject:               pkix.Name{CommonName: "client"},
+		NotBefore:             time.Unix(1521644867, 0),
+		NotAfter:              time.Unix(1521644867, 0).Add(time.Hour),
+		BasicConstraintsValid: true,
+		IsCA:                  parent == nil,
+	}
+	for _, opt := range opts {
+		opt(tmpl)
+	}
+	if parent == nil {
+		parent, parentKey = tmpl, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, parent, key.Public(), parentKey)
+	if err != nil {
+		t.Fatalf("Unexpected error occur: expect:%#v", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("Unexpected error occur: expect:%#v", err)
+	}
+	return cert
+}
+
+func TestConfirmations(t *testing.T) {
+	caKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	otherCAKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	clientKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	otherKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+
+	ca := createCertificate(t, 1, caKey, nil, nil)
+	otherCA := createCertificate(t, 2, otherCAKey, nil, nil)
+	leaf := createCertificate(t, 3, clientKey, ca, caKey)
+	renewed := createCertificate(t, 4, clientKey, ca, caKey)
+	rotated := createCertificate(t, 5, otherKey, ca, caKey)
+	foreign := createCertificate(t, 6, clientKey, otherCA, otherCAKey)
+
+	state := func(certs ...*x509.Certificate) *tls.ConnectionState {
+		return &tls.ConnectionState{PeerCertificates: certs}
+	}
+	verified := func(certs ...*x509.Certificate) *tls.ConnectionState {
+		return &tls.ConnectionState{
+			PeerCertificates: certs[:1],
+			VerifiedChains:   [][]*x509.Certificate{certs},
+		}
+	}
+
+	timeFunc = func() time.Time {
+		return time.Unix(1521644867, 0)
+	}
+
+	tcs := map[string]struct {
+		cnf    Confirmation
+		accept []Confirmation
+		state  *tls.ConnectionState
+		err    error
+	}{
+		"certificate": {
+			cnf:   CertificateConfirmation,
+			state: state(leaf, ca),
+		},
+		"certificate renewed": {
+			cnf:   CertificateConfirmation,
+			state: state(renewed, ca),
+			err:   ErrVerifyPoP,
+		},
+		"public key renewed": {
+			cnf:    PublicKeyConfirmation,
+			accept: []Confirmation{PublicKeyConfirmation},
+			state:  state(renewed, ca),
+		},
+		"public key rotated": {
+			cnf:    PublicKeyConfirmation,
+			accept: []Confirmation{PublicKeyConfirmation},
+			state:  state(rotated, ca),
+			err:    ErrVerifyPoP,
+		},
+		"public key not accepted": {
+			cnf:   PublicKeyConfirmation,
+			state: state(renewed, ca),
+			err:   ErrVerifyPoP,
+		},
+		"intermediate rotated": {
+			cnf:    IntermediateConfirmation,
+			accept: []Confirmation{CertificateConfirmation, IntermediateConfirmation},
+			state:  verified(rotated, ca),
+		},
+		"intermediate not verified": {
+			cnf:    IntermediateConfirmation,
+			accept: []Confirmation{CertificateConfirmation, IntermediateConfirmation},
+			state:  state(rotated, ca),
+			err:    ErrVerifyPoP,
+		},
+		"intermediate other ca": {
+			cnf:    IntermediateConfirmation,
+			accept: []Confirmation{IntermediateConfirmation},
+			state:  verified(foreign, otherCA),
+			err:    ErrVerifyPoP,
+		},
+		"intermediate not sent": {
+			cnf:    IntermediateConfirmation,
+			accept: []Confirmation{IntermediateConfirmation},
+			state:  verified(rotated),
+			err:    ErrVerifyPoP,
+		},
+	}
+
+	for name, tc := range tcs {
+		token, err := IssueToken(verified(leaf, ca), []byte("secret"), RawClaims{}, WithConfirmation(tc.cnf))
+		if err != nil {
+			t.Fatalf("Unexpected error occur in %s: expect:%#v", name, err)
+		}
+
+		_, err = DecodeToken(tc.state, token, []byte("secret"), WithAcceptedConfirmations(tc.accept...))
+		if tc.err == nil && err != nil {
+			t.Errorf("Unexpected error occur: %s: %#v", name, err)
+		}
+		if tc.err != nil && !errors.Is(err, tc.err) {
+			t.Errorf("Unexpeccted error occur: %s: expect:%#v, given:%#v", name, tc.err, err)
+		}
+	}
+}
+
+func TestThumbprintFuncs(t *testing.T) {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	cert := createCertificate(t, 1, key, nil, nil)
+	leaf := createCertificate(t, 2, key, cert, key)
+	otherKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	other := createCertificate(t, 3, otherKey, nil, nil)
+
+	tcs := map[string]struct {
+		f      ThumbprintFunc
+		certs  []*x509.Certificate
+		expect string
+	}{
+		"certificate": {
+			f:      CertificateThumbprint,
+			certs:  []*x509.Certificate{cert},
+			expect: encodeThumbprint(cert.Raw),
+		},
+		"public key": {
+			f:      PublicKeyThumbprint,
+			certs:  []*x509.Certificate{cert},
+			expect: encodeThumbprint(cert.RawSubjectPublicKeyInfo),
+		},
+		"intermediate": {
+			f:      IntermediateThumbprint,
+			certs:  []*x509.Certificate{leaf, cert},
+			expect: encodeThumbprint(cert.Raw),
+		},
+		"intermediate not issuer": {
+			f:     IntermediateThumbprint,
+			certs: []*x509.Certificate{other, cert},
+		},
+		"no certificate": {
+			f: CertificateThumbprint,
+		},
+	}
+
+	for name, tc := range tcs {
+		tp, err := tc.f(tc.certs)
+		if tc.expect == "" {
+			if err == nil {
+				t.Errorf("Should be error occur in %s", name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("Unexpected error occur in %s: expect:%#v", name, err)
+		}
+		if tp != tc.expect {
+			t.Errorf("Unexpected thumbprint: %s: expect:%#v, given:%#v", name, tc.expect, tp)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package mtoken
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// tokenExpiryDelta is how early the cached token is refreshed before exp.
+const tokenExpiryDelta = 10 * time.Second
+
+// TokenSource provides the access token used by the client.
+type TokenSource interface {
+	Token(ctx context.Context) (string, error)
+}
+
+// TokenSourceFunc is the function used as TokenSource.
+type TokenSourceFunc func(ctx context.Context) (string, error)
+
+// Token calls f(ctx).
+func (f TokenSourceFunc) Token(ctx context.Context) (string, error) {
+	return f(ctx)
+}
+
+// CachedTokenSource caches the token of the source until it expires.
+// exp of the token is read without verifying the signature.
+type CachedTokenSource struct {
+	source TokenSource
+	clock  func() time.Time
+
+	mu     sync.Mutex
+	token  string
+	expiry time.Time
+}
+
+// NewCachedTokenSource creates CachedTokenSource.
+func NewCachedTokenSource(source TokenSource) *CachedTokenSource {
+	return &CachedTokenSource{
+		source: source,
+		clock:  time.Now,
+	}
+}
+
+// Token returns the cached token, or gets new one if it is about to expire.
+func (s *CachedTokenSource) Token(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.token != "" && s.clock().Add(tokenExpiryDelta).Before(s.expiry) {
+		return s.token, nil
+	}
+
+	token, err := s.source.Token(ctx)
+	if err != nil {
+		return "", err
+	}
+	jwt, err := Parse(token)
+	if err != nil {
+		return "", err
+	}
+
+	// The token without exp is not cached.
+	s.token, s.expiry = "", time.Time{}
+	if exp, err := jwt.claims.GetInt64("exp"); err == nil {
+		s.token, s.expiry = token, time.Unix(exp, 0)
+	}
+	return token, nil
+}
+
+// Invalidate drops the cached token if it is token.
+// It is called when the token is rejected by the server.
+func (s *CachedTokenSource) Invalidate(token string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.token == token {
+		s.token, s.expiry = "", time.Time{}
+	}
+}
@@ -0,0 +1,47 @@
+package mtoken
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestCachedTokenSource(t *testing.T) {
+	now := time.Unix(1521644867, 0)
+	timeFunc = func() time.Time { return now }
+
+	count := 0
+	source := NewCachedTokenSource(TokenSourceFunc(func(ctx context.Context) (string, error) {
+		count++
+		return IssueToken(getTLSState(), []byte("secret"), RawClaims{}, WithLifetime(time.Minute))
+	}))
+	source.clock = func() time.Time { return now }
+
+	token1, err := source.Token(context.Background())
+	if err != nil {
+		t.Fatalf("Unexpected error occur: expect:%#v", err)
+	}
+	if token, _ := source.Token(context.Background()); token != token1 || count != 1 {
+		t.Errorf("Token must be cached")
+	}
+
+	// refresh before exp.
+	now = now.Add(55 * time.Second)
+	token2, err := source.Token(context.Background())
+	if err != nil {
+		t.Fatalf("Unexpected error occur: expect:%#v", err)
+	}
+	if count != 2 || token2 == token1 {
+		t.Errorf("Token must be refreshed before exp")
+	}
+
+	// stale token does not drop the current one.
+	source.Invalidate(token1)
+	if token, _ := source.Token(context.Background()); token != token2 || count != 2 {
+		t.Errorf("Token must not be invalidated")
+	}
+	source.Invalidate(token2)
+	if _, _ = source.Token(context.Background()); count != 3 {
+		t.Errorf("Token must be invalidated")
+	}
+}
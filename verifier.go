@@ -0,0 +1,309 @@
+package mtoken
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"time"
+)
+
+// Verifier verifies certificate-bound tokens.
+type Verifier struct {
+	keyFunc   func(kid string) (interface{}, error)
+	issuers   []string
+	audiences []string
+	leeway    time.Duration
+	required  []string
+	maxAge    time.Duration
+	clock     func() time.Time
+	algs      []string
+	cnfs      []Confirmation
+}
+
+// VerifierOption configures Verifier.
+type VerifierOption func(*Verifier)
+
+// WithExpectedIssuers sets the accepted values of iss.
+// If it is not set, iss is not checked.
+func WithExpectedIssuers(issuers ...string) VerifierOption {
+	return func(v *Verifier) {
+		v.issuers = append(v.issuers, issuers...)
+	}
+}
+
+// WithExpectedAudiences sets the accepted values of aud.
+// The token is accepted when one of its audiences is in the list.
+// If it is not set, aud is not checked.
+func WithExpectedAudiences(audiences ...string) VerifierOption {
+	return func(v *Verifier) {
+		v.audiences = append(v.audiences, audiences...)
+	}
+}
+
+// WithLeeway sets the clock skew tolerance used for exp, iat and nbf.
+func WithLeeway(leeway time.Duration) VerifierOption {
+	return func(v *Verifier) {
+		v.leeway = leeway
+	}
+}
+
+// WithRequiredClaims sets the claim names which must be in the token.
+func WithRequiredClaims(names ...string) VerifierOption {
+	return func(v *Verifier) {
+		v.required = append(v.required, names...)
+	}
+}
+
+// WithMaxAge rejects tokens whose iat is older than maxAge.
+func WithMaxAge(maxAge time.Duration) VerifierOption {
+	return func(v *Verifier) {
+		v.maxAge = maxAge
+	}
+}
+
+// WithClock sets the function which returns the current time.
+func WithClock(clock func() time.Time) VerifierOption {
+	return func(v *Verifier) {
+		v.clock = clock
+	}
+}
+
+// WithAllowedAlgorithms restricts alg of the token to the names.
+// It prevents the token from being verified with an unintended method,
+// e.g. HS256 with the bytes of an RSA public key.
+// If it is not set, every registered method is accepted.
+func WithAllowedAlgorithms(names ...string) VerifierOption {
+	return func(v *Verifier) {
+		v.algs = append(v.algs, names...)
+	}
+}
+
+// WithAcceptedConfirmations sets how the token can be bound to the client certificate.
+// The token is accepted when it has one of the members in cnf and its thumbprint matches.
+// The default is CertificateConfirmation.
+func WithAcceptedConfirmations(cnfs ...Confirmation) VerifierOption {
+	return func(v *Verifier) {
+		v.cnfs = append(v.cnfs, cnfs...)
+	}
+}
+
+// NewVerifier creates Verifier.
+// If publicKey is KeySource, the key is looked up by kid of the token.
+func NewVerifier(publicKey interface{}, opts ...VerifierOption) (*Verifier, error) {
+	if publicKey == nil {
+		return nil, ErrKeyPair
+	}
+
+	v := &Verifier{
+		clock: func() time.Time {
+			return timeFunc()
+		},
+	}
+	switch k := publicKey.(type) {
+	case KeySource:
+		v.keyFunc = k.LookupKey
+	default:
+		v.keyFunc = func(string) (interface{}, error) {
+			return publicKey, nil
+		}
+	}
+	for _, opt := range opts {
+		opt(v)
+	}
+	if len(v.cnfs) == 0 {
+		v.cnfs = []Confirmation{CertificateConfirmation}
+	}
+	return v, nil
+}
+
+// Verify verifies the token and returns it.
+// The token must be bound to the client certificate of state.
+// Verification failures are returned as *ValidationError.
+func (v *Verifier) Verify(state *tls.ConnectionState, jwtString string) (*JWT, error) {
+	if state == nil {
+		return nil, ErrMutualTLSConnection
+	}
+
+	jwt, err := v.verifyToken(jwtString)
+	if err != nil {
+		return nil, err
+	}
+
+	// proof of possession
+	if err := VerifyPoP(state, jwt.claims, v.cnfs...); err != nil {
+		return nil, err
+	}
+
+	return jwt, nil
+}
+
+// VerifyWithoutPoP verifies the token except for the proof of possession.
+// It is used when the client certificate is not known, e.g. by the
+// introspection endpoint. The proof of possession must be checked by
+// VerifyPoP where the client certificate is known.
+func (v *Verifier) VerifyWithoutPoP(jwtString string) (*JWT, error) {
+	return v.verifyToken(jwtString)
+}
+
+// VerifyCertificates verifies the token bound to the client certificate chain.
+// It is used when TLS is terminated before the server, e.g. by a proxy.
+// certs[0] must be the client certificate. verified is whether the chain is
+// verified by the TLS handshake or the proxy, which IntermediateConfirmation requires.
+func (v *Verifier) VerifyCertificates(certs []*x509.Certificate, verified bool, jwtString string) (*JWT, error) {
+	if len(certs) == 0 {
+		return nil, ErrMutualTLSConnection
+	}
+
+	jwt, err := v.verifyToken(jwtString)
+	if err != nil {
+		return nil, err
+	}
+	if err := verifyConfirmation(jwt.claims, certs, verified, v.cnfs); err != nil {
+		return nil, err
+	}
+	return jwt, nil
+}
+
+// VerifyThumbprint verifies the token bound to the client certificate whose
+// x5t#S256 thumbprint is given. It is used when only the hash of the client
+// certificate is known. CertificateConfirmation must be accepted.
+func (v *Verifier) VerifyThumbprint(thumbprint, jwtString string) (*JWT, error) {
+	if thumbprint == "" {
+		return nil, ErrMutualTLSConnection
+	}
+
+	jwt, err := v.verifyToken(jwtString)
+	if err != nil {
+		return nil, err
+	}
+	for _, cnf := range v.cnfs {
+		if cnf.Member != CertificateConfirmation.Member {
+			continue
+		}
+		if jwt.claims.GetConfirmation(cnf.Member) != thumbprint {
+			break
+		}
+		return jwt, nil
+	}
+	return nil, newValidationError(ReasonPoPMismatch, ErrVerifyPoP)
+}
+
+// verifyToken verifies the token except for the proof of possession.
+func (v *Verifier) verifyToken(jwtString string) (*JWT, error) {
+	jwt, err := Parse(jwtString)
+	if err != nil {
+		return nil, err
+	}
+	if len(v.algs) > 0 && !contains(v.algs, jwt.method.Name()) {
+		return nil, newValidationError(ReasonUnsupportedAlg, ErrUnsupportedAlg)
+	}
+
+	// verify signature
+	kid, _ := jwt.header.GetString("kid")
+	key, err := v.keyFunc(kid)
+	if err != nil {
+		return nil, newValidationError(ReasonSignature, err)
+	}
+	if err := jwt.verifyJWT(key); err != nil {
+		return nil, err
+	}
+
+	// verify token claims
+	if err := v.verifyClaims(jwt.claims); err != nil {
+		return nil, err
+	}
+	return jwt, nil
+}
+
+// verifyConfirmation checks the first accepted member which the token has.
+// verified is whether the chain is verified by the TLS handshake.
+func verifyConfirmation(claims RawClaims, certs []*x509.Certificate, verified bool, cnfs []Confirmation) error {
+	for _, cnf := range cnfs {
+		expected := claims.GetConfirmation(cnf.Member)
+		if expected == "" {
+			continue
+		}
+		if cnf.RequireVerifiedChain && !verified {
+			return newValidationError(ReasonPoPMismatch, ErrVerifyPoP)
+		}
+		tp, err := cnf.Thumbprint(certs)
+		if err != nil {
+			return newValidationError(ReasonPoPMismatch, err)
+		}
+		if tp != expected {
+			return newValidationError(ReasonPoPMismatch, ErrVerifyPoP)
+		}
+		return nil
+	}
+	return newValidationError(ReasonPoPMismatch, ErrVerifyPoP)
+}
+
+func (v *Verifier) verifyClaims(claims RawClaims) error {
+	for _, name := range v.required {
+		if _, ok := claims[name]; !ok {
+			return newValidationError(ReasonMalformed, ErrTokenClaims)
+		}
+	}
+
+	now := v.clock()
+
+	// The claims which are absent or not numbers are malformed,
+	// so that the caller does not wait or refresh for them.
+	iat, err := claims.GetInt64("iat")
+	if err != nil {
+		return newValidationError(ReasonMalformed, ErrTokenIat)
+	}
+	if time.Unix(iat, 0).After(now.Add(v.leeway)) {
+		return newValidationError(ReasonNotYetValid, ErrTokenIat)
+	}
+	exp, err := claims.GetInt64("exp")
+	if err != nil {
+		return newValidationError(ReasonMalformed, ErrTokenExpire)
+	}
+	if !time.Unix(exp, 0).After(now.Add(-v.leeway)) {
+		return newValidationError(ReasonExpired, ErrTokenExpire)
+	}
+	if _, ok := claims["nbf"]; ok {
+		nbf, err := claims.GetInt64("nbf")
+		if err != nil {
+			return newValidationError(ReasonMalformed, ErrTokenNotBefore)
+		}
+		if time.Unix(nbf, 0).After(now.Add(v.leeway)) {
+			return newValidationError(ReasonNotYetValid, ErrTokenNotBefore)
+		}
+	}
+	if v.maxAge > 0 && now.Sub(time.Unix(iat, 0)) > v.maxAge+v.leeway {
+		return newValidationError(ReasonExpired, ErrTokenExpire)
+	}
+
+	if len(v.issuers) > 0 {
+		iss, err := claims.GetString("iss")
+		if err != nil || !contains(v.issuers, iss) {
+			return newValidationError(ReasonIssuer, ErrTokenIssuer)
+		}
+	}
+	if len(v.audiences) > 0 {
+		auds, err := claims.GetStrings("aud")
+		if err != nil || !containsAny(v.audiences, auds) {
+			return newValidationError(ReasonAudience, ErrTokenAudience)
+		}
+	}
+	return nil
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func containsAny(list []string, ss []string) bool {
+	for _, s := range ss {
+		if contains(list, s) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,217 @@
+package mtoken
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestVerifierVerify(t *testing.T) {
+	secret := []byte("secret")
+	now := time.Unix(1521644867, 0)
+	clock := func() time.Time { return now }
+
+	timeFunc = clock
+	state := getTLSState()
+	issue := func(rc RawClaims) string {
+		tokenStr, err := IssueToken(state, secret, rc)
+		if err != nil {
+			t.Fatalf("Unexpected error occur: expect:%#v", err)
+		}
+		return tokenStr
+	}
+
+	tcs := map[string]struct {
+		token string
+		state *tls.ConnectionState
+		opts  []VerifierOption
+		err   error
+	}{
+		"default": {
+			token: issue(RawClaims{"iss": "iss"}),
+		},
+		"expected issuer": {
+			token: issue(RawClaims{"iss": "iss"}),
+			opts:  []VerifierOption{WithExpectedIssuers("other", "iss")},
+		},
+		"unexpected issuer": {
+			token: issue(RawClaims{"iss": "iss"}),
+			opts:  []VerifierOption{WithExpectedIssuers("other")},
+			err:   ErrTokenIssuer,
+		},
+		"expected audience": {
+			token: issue(RawClaims{"aud": []string{"rs1", "rs2"}}),
+			opts:  []VerifierOption{WithExpectedAudiences("rs2")},
+		},
+		"unexpected audience": {
+			token: issue(RawClaims{"aud": "rs1"}),
+			opts:  []VerifierOption{WithExpectedAudiences("rs2")},
+			err:   ErrTokenAudience,
+		},
+		"no audience": {
+			token: issue(RawClaims{"iss": "iss"}),
+			opts:  []VerifierOption{WithExpectedAudiences("rs2")},
+			err:   ErrTokenAudience,
+		},
+		"required claims": {
+			token: issue(RawClaims{"iss": "iss", "sub": "sub"}),
+			opts:  []VerifierOption{WithRequiredClaims("iss", "sub")},
+		},
+		"missing required claims": {
+			token: issue(RawClaims{"iss": "iss"}),
+			opts:  []VerifierOption{WithRequiredClaims("iss", "sub")},
+			err:   ErrTokenClaims,
+		},
+		"expired": {
+			token: issue(RawClaims{"exp": now.Add(-time.Second).Unix()}),
+			err:   ErrTokenExpire,
+		},
+		"expired within leeway": {
+			token: issue(RawClaims{"exp": now.Add(-time.Second).Unix()}),
+			opts:  []VerifierOption{WithLeeway(time.Minute)},
+		},
+		"issued in future": {
+			token: issue(RawClaims{"iat": now.Add(time.Second).Unix()}),
+			err:   ErrTokenIat,
+		},
+		"issued in future within leeway": {
+			token: issue(RawClaims{"iat": now.Add(time.Second).Unix()}),
+			opts:  []VerifierOption{WithLeeway(time.Minute)},
+		},
+		"not before": {
+			token: issue(RawClaims{"nbf": now.Add(time.Second).Unix()}),
+			err:   ErrTokenNotBefore,
+		},
+		"too old": {
+			token: issue(RawClaims{"iat": now.Add(-time.Hour).Unix(), "exp": now.Add(time.Hour).Unix()}),
+			opts:  []VerifierOption{WithMaxAge(time.Minute)},
+			err:   ErrTokenExpire,
+		},
+		"clock": {
+			token: issue(RawClaims{"iss": "iss"}),
+			opts:  []VerifierOption{WithClock(func() time.Time { return now.Add(2 * time.Hour) })},
+			err:   ErrTokenExpire,
+		},
+		"allowed algorithm": {
+			token: issue(RawClaims{"iss": "iss"}),
+			opts:  []VerifierOption{WithAllowedAlgorithms("HS256", "HS512")},
+		},
+		"not allowed algorithm": {
+			token: issue(RawClaims{"iss": "iss"}),
+			opts:  []VerifierOption{WithAllowedAlgorithms("RS256")},
+			err:   ErrUnsupportedAlg,
+		},
+		"other certificate": {
+			token: issue(RawClaims{"iss": "iss"}),
+			state: &tls.ConnectionState{
+				PeerCertificates: []*x509.Certificate{
+					{Raw: []byte("other certificate")},
+				},
+			},
+			err: ErrVerifyPoP,
+		},
+	}
+
+	for name, tc := range tcs {
+		v, err := NewVerifier(secret, tc.opts...)
+		if err != nil {
+			t.Fatalf("Unexpected error occur in %s: expect:%#v", name, err)
+		}
+		if tc.state == nil {
+			tc.state = state
+		}
+		jwt, err := v.Verify(tc.state, tc.token)
+		if !errors.Is(err, tc.err) {
+			t.Errorf("Unexpected error occur: %s: expect:%#v, given:%#v", name, tc.err, err)
+			continue
+		}
+		if err == nil && jwt == nil {
+			t.Errorf("jwt must be gotten: %s", name)
+		}
+	}
+}
+
+func TestVerifierWithoutTLSState(t *testing.T) {
+	secret := []byte("secret")
+	timeFunc = func() time.Time {
+		return time.Unix(1521644867, 0)
+	}
+	state := getTLSState()
+	tokenStr, err := IssueToken(state, secret, RawClaims{"iss": "iss"})
+	if err != nil {
+		t.Fatalf("Unexpected error occur: expect:%#v", err)
+	}
+	tp, err := getThumbprintFromTLSState(state)
+	if err != nil {
+		t.Fatalf("Unexpected error occur: expect:%#v", err)
+	}
+	other := []*x509.Certificate{{Raw: []byte("other certificate")}}
+
+	verifier, err := NewVerifier(secret)
+	if err != nil {
+		t.Fatalf("Unexpected error occur: expect:%#v", err)
+	}
+	if _, err := verifier.VerifyCertificates(state.PeerCertificates, true, tokenStr); err != nil {
+		t.Errorf("Unexpected error occur: %#v", err)
+	}
+	if _, err := verifier.VerifyCertificates(other, true, tokenStr); !errors.Is(err, ErrVerifyPoP) {
+		t.Errorf("Unexpeccted error occur: expect:%#v, given:%#v", ErrVerifyPoP, err)
+	}
+	if _, err := verifier.VerifyThumbprint(tp, tokenStr); err != nil {
+		t.Errorf("Unexpected error occur: %#v", err)
+	}
+	if _, err := verifier.VerifyThumbprint("other", tokenStr); !errors.Is(err, ErrVerifyPoP) {
+		t.Errorf("Unexpeccted error occur: expect:%#v, given:%#v", ErrVerifyPoP, err)
+	}
+
+	// thumbprint is x5t#S256 of the certificate.
+	verifier, err = NewVerifier(secret, WithAcceptedConfirmations(PublicKeyConfirmation))
+	if err != nil {
+		t.Fatalf("Unexpected error occur: expect:%#v", err)
+	}
+	if _, err := verifier.VerifyThumbprint(tp, tokenStr); !errors.Is(err, ErrVerifyPoP) {
+		t.Errorf("Unexpeccted error occur: expect:%#v, given:%#v", ErrVerifyPoP, err)
+	}
+}
+
+func TestVerifyWithoutPoP(t *testing.T) {
+	secret := []byte("secret")
+	timeFunc = func() time.Time {
+		return time.Unix(1521644867, 0)
+	}
+	state := getTLSState()
+	tokenStr, err := IssueToken(state, secret, RawClaims{"iss": "iss"})
+	if err != nil {
+		t.Fatalf("Unexpected error occur: expect:%#v", err)
+	}
+	other := &tls.ConnectionState{
+		PeerCertificates: []*x509.Certificate{{Raw: []byte("other certificate")}},
+	}
+
+	verifier, err := NewVerifier(secret)
+	if err != nil {
+		t.Fatalf("Unexpected error occur: expect:%#v", err)
+	}
+	jwt, err := verifier.VerifyWithoutPoP(tokenStr)
+	if err != nil {
+		t.Fatalf("Unexpected error occur: expect:%#v", err)
+	}
+	if _, err := verifier.VerifyWithoutPoP(tokenStr + "a"); !errors.Is(err, ErrSignature) {
+		t.Errorf("Unexpeccted error occur: expect:%#v, given:%#v", ErrSignature, err)
+	}
+
+	if err := VerifyPoP(state, jwt.Claims()); err != nil {
+		t.Errorf("Unexpected error occur: %#v", err)
+	}
+	if err := VerifyPoP(other, jwt.Claims()); !errors.Is(err, ErrVerifyPoP) {
+		t.Errorf("Unexpeccted error occur: expect:%#v, given:%#v", ErrVerifyPoP, err)
+	}
+	if err := VerifyPoP(nil, jwt.Claims()); err != ErrMutualTLSConnection {
+		t.Errorf("Unexpeccted error occur: expect:%#v, given:%#v", ErrMutualTLSConnection, err)
+	}
+	if err := VerifyPoP(state, jwt.Claims(), PublicKeyConfirmation); !errors.Is(err, ErrVerifyPoP) {
+		t.Errorf("Unexpeccted error occur: expect:%#v, given:%#v", ErrVerifyPoP, err)
+	}
+}